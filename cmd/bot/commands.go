@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/config"
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/repository/postgres"
+	"github.com/X1ag/TravelScheduler/internal/utils"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// connect loads the configuration without bot or API credentials, which
+// operator commands don't need, and opens a pool
+func connect(ctx context.Context, configPath string) (*pgxpool.Pool, error) {
+	cfg, err := config.Load(configPath, 0)
+	if err != nil {
+		return nil, err
+	}
+	setupLogging(cfg)
+	return pgxpool.New(ctx, cfg.DB.ConnString())
+}
+
+func migrateCmd(configPath string, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|status|force VERSION")
+	}
+
+	cfg, err := config.Load(configPath, 0)
+	if err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	dsn := cfg.DB.ConnString()
+
+	switch args[0] {
+	case "up":
+		return postgres.RunMigrations(dsn)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: N must be a positive number, got %q", args[1])
+			}
+		}
+		return postgres.RollbackMigrations(dsn, steps)
+	case "status":
+		version, dirty, err := postgres.MigrationVersion(dsn)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d\n", version)
+		if dirty {
+			fmt.Println("dirty: the last migration failed, fix it and run `migrate force VERSION`")
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errors.New("usage: migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("migrate force: invalid version %q", args[1])
+		}
+		return postgres.ForceMigrationVersion(dsn, version)
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func importStationsCmd(ctx context.Context, configPath string, args []string) error {
+	fs := flag.NewFlagSet("import-stations", flag.ExitOnError)
+	file := fs.String("file", "", "CSV file with code,name rows; built-in popular stations if empty")
+	fs.Parse(args)
+
+	var stations []domain.Station
+	if *file == "" {
+		for _, s := range utils.PopularStations {
+			stations = append(stations, domain.Station{Code: s.Code, Name: s.DisplayName})
+		}
+	} else {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if stations, err = readStations(f); err != nil {
+			return fmt.Errorf("read %s: %w", *file, err)
+		}
+	}
+
+	pool, err := connect(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	n, err := postgres.NewStationRepository(pool).Upsert(ctx, stations)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d stations\n", n)
+	return nil
+}
+
+// readStations parses code,name rows; a header row starting with "code" is
+// skipped
+func readStations(r io.Reader) ([]domain.Station, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+
+	var stations []domain.Station
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(stations) == 0 && strings.EqualFold(rec[0], "code") {
+			continue
+		}
+		code, name := strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])
+		if code == "" || name == "" {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: code and name must not be empty", line)
+		}
+		stations = append(stations, domain.Station{Code: code, Name: name})
+	}
+	return stations, nil
+}
+
+func sendTestReminderCmd(ctx context.Context, configPath string, args []string) error {
+	fs := flag.NewFlagSet("send-test-reminder", flag.ExitOnError)
+	telegramID := fs.Int64("telegram-id", 0, "telegram id of the recipient")
+	text := fs.String("text", "🔔 Тестовое напоминание", "reminder text")
+	fs.Parse(args)
+
+	if *telegramID == 0 {
+		return errors.New("send-test-reminder: -telegram-id is required")
+	}
+
+	pool, err := connect(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	user, err := postgres.NewUserRepository(pool).GetByTelegramID(ctx, *telegramID)
+	if err != nil {
+		return fmt.Errorf("find user %d: %w", *telegramID, err)
+	}
+
+	// The running worker picks the reminder up, so this checks the whole
+	// delivery path rather than just the Telegram token
+	rem := &domain.Reminder{
+		UserID:    user.ID,
+		Message:   *text,
+		TriggerAt: time.Now(),
+		Status:    string(domain.StatusPending),
+	}
+	if err := postgres.NewReminderRepository(pool).Create(ctx, rem); err != nil {
+		return err
+	}
+	fmt.Printf("queued reminder %d for user %d\n", rem.ID, user.ID)
+	return nil
+}
+
+func usersCmd(ctx context.Context, configPath string, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("usage: users list")
+	}
+
+	pool, err := connect(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	users, err := postgres.NewUserRepository(pool).List(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTELEGRAM ID\tUSERNAME\tNAME\tACTIVE")
+	for _, u := range users {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%t\n", u.ID, u.TelegramID, u.Username, u.Name, u.IsActive)
+	}
+	return tw.Flush()
+}
+
+type userExport struct {
+	User      *domain.User         `json:"user"`
+	Settings  *domain.UserSettings `json:"settings"`
+	Trips     []*domain.Trip       `json:"trips"`
+	Books     []*domain.Book       `json:"books"`
+	Reminders []*domain.Reminder   `json:"reminders"`
+}
+
+func exportCmd(ctx context.Context, configPath string, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	telegramID := fs.Int64("telegram-id", 0, "export a single user; all users if 0")
+	fs.Parse(args)
+
+	pool, err := connect(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	userRepo := postgres.NewUserRepository(pool)
+	settingsRepo := postgres.NewSettingsRepository(pool)
+	tripRepo := postgres.NewTripRepository(pool)
+	bookRepo := postgres.NewBookRepository(pool)
+	reminderRepo := postgres.NewReminderRepository(pool)
+
+	var users []*domain.User
+	if *telegramID != 0 {
+		user, err := userRepo.GetByTelegramID(ctx, *telegramID)
+		if err != nil {
+			return fmt.Errorf("find user %d: %w", *telegramID, err)
+		}
+		users = []*domain.User{user}
+	} else if users, err = userRepo.List(ctx); err != nil {
+		return err
+	}
+
+	out := make([]userExport, 0, len(users))
+	for _, u := range users {
+		e := userExport{User: u}
+		if e.Settings, err = settingsRepo.GetByUserID(ctx, u.ID); err != nil {
+			if !errors.Is(err, domain.ErrSettingsNotFound) {
+				return err
+			}
+			e.Settings = domain.DefaultUserSettings(u.ID)
+		}
+		if e.Trips, err = tripRepo.GetByUserID(ctx, u.ID); err != nil {
+			return err
+		}
+		if e.Books, err = bookRepo.GetByUserID(ctx, u.ID); err != nil {
+			return err
+		}
+		if e.Reminders, err = reminderRepo.GetByUserID(ctx, u.ID); err != nil {
+			return err
+		}
+		out = append(out, e)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/config"
+	"github.com/X1ag/TravelScheduler/internal/infrastructure/yandex"
+	"github.com/X1ag/TravelScheduler/transport/httpapi"
+	"github.com/X1ag/TravelScheduler/transport/telegram"
+	"github.com/X1ag/TravelScheduler/transport/worker"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func healthChecks(pool *pgxpool.Pool, b *telegram.Bot, w *worker.Worker, breaker *yandex.BreakerProvider) []httpapi.HealthCheck {
+	return []httpapi.HealthCheck{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) (map[string]any, error) {
+				stat := pool.Stat()
+				details := map[string]any{
+					"total_connections":    stat.TotalConns(),
+					"acquired_connections": stat.AcquiredConns(),
+				}
+				return details, pool.Ping(ctx)
+			},
+		},
+		{
+			Name: "telegram",
+			Check: func(ctx context.Context) (map[string]any, error) {
+				mode, ok := b.Receiving()
+				if !ok {
+					return map[string]any{"mode": mode}, errors.New("not receiving updates")
+				}
+				return map[string]any{"mode": mode}, nil
+			},
+		},
+		{
+			Name:     "worker",
+			Liveness: true,
+			Check: func(ctx context.Context) (map[string]any, error) {
+				last := w.LastTick()
+				if last.IsZero() {
+					return nil, errors.New("no successful tick yet")
+				}
+				details := map[string]any{"last_tick": last.Format(time.RFC3339)}
+				if age := time.Since(last); age > w.MaxTickAge() {
+					return details, fmt.Errorf("last tick %s ago", age.Round(time.Second))
+				}
+				return details, nil
+			},
+		},
+		{
+			Name:     "yandex",
+			Optional: true,
+			Check: func(ctx context.Context) (map[string]any, error) {
+				state, openedAt := breaker.State()
+				details := map[string]any{"circuit_breaker": state}
+				if state == yandex.BreakerClosed {
+					return details, nil
+				}
+				details["opened_at"] = openedAt.Format(time.RFC3339)
+				return details, errors.New("circuit breaker is " + string(state))
+			},
+		},
+	}
+}
+
+// healthcheckCmd probes /healthz of a running instance; it is the Docker
+// HEALTHCHECK since the runtime image has no curl
+func healthcheckCmd(ctx context.Context, configPath string, args []string) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	target := fs.String("url", "", "health endpoint; derived from HTTP_ADDR if empty")
+	fs.Parse(args)
+
+	if *target == "" {
+		cfg, err := config.Load(configPath, 0)
+		if err != nil {
+			return err
+		}
+		if cfg.HTTP.Addr == "" {
+			return errors.New("HTTP_ADDR is not set")
+		}
+		host, port, err := net.SplitHostPort(cfg.HTTP.Addr)
+		if err != nil {
+			return fmt.Errorf("HTTP_ADDR: %w", err)
+		}
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		*target = "http://" + net.JoinHostPort(host, port) + "/healthz"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", *target, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/X1ag/TravelScheduler/config"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/infrastructure/yandex"
+	"github.com/X1ag/TravelScheduler/internal/lifecycle"
+	"github.com/X1ag/TravelScheduler/internal/metrics"
+	"github.com/X1ag/TravelScheduler/internal/repository/postgres"
+	"github.com/X1ag/TravelScheduler/internal/usecase"
+	"github.com/X1ag/TravelScheduler/transport/httpapi"
+	"github.com/X1ag/TravelScheduler/transport/telegram"
+	"github.com/X1ag/TravelScheduler/transport/worker"
+	"github.com/go-telegram/bot"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serve migrates the database and runs the bot until ctx is cancelled
+func serve(ctx context.Context, configPath string) error {
+	cfg, err := config.Load(configPath, config.RequireAll)
+	if err != nil {
+		return err
+	}
+	setupLogging(cfg)
+
+	if missing := i18n.Missing(); len(missing) > 0 {
+		slog.Warn("message catalogs are incomplete, the default language is used instead", "keys", missing)
+	}
+
+	dsn := cfg.DB.ConnString()
+	if err := postgres.RunMigrations(dsn); err != nil {
+		return err
+	}
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return err
+	}
+	poolCfg.ConnConfig.Tracer = metrics.QueryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	metrics.RegisterPool(pool)
+
+	tripRepo := postgres.NewTripRepository(pool)
+	reminderRepo := postgres.NewReminderRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	bookRepo := postgres.NewBookRepository(pool)
+	settingsRepo := postgres.NewSettingsRepository(pool)
+	stationRepo := postgres.NewStationRepository(pool)
+	tx := postgres.NewTransactor(pool)
+
+	yandexAPI := yandex.NewClient(cfg.Yandex.APIKey, cfg.Yandex.Timeout)
+	breaker := yandex.NewBreakerProvider(yandexAPI, cfg.Yandex.BreakerThreshold, cfg.Yandex.BreakerCooldown)
+	yandexClient := yandex.NewCachedProvider(breaker, cfg.Cache.ScheduleTTL)
+
+	tripUC := usecase.NewTripUsecase(tripRepo, reminderRepo, settingsRepo, stationRepo, yandexClient, tx)
+	bookUC := usecase.NewBookUsecase(bookRepo, userRepo, reminderRepo, tripRepo, settingsRepo, tx)
+	userUC := usecase.NewUserUsecase(userRepo, reminderRepo, tripRepo, tx)
+	settingsUC := usecase.NewSettingsUsecase(settingsRepo)
+	reminderUC := usecase.NewReminderUsecase(reminderRepo, settingsRepo)
+	adminUC := usecase.NewAdminUsecase(userRepo, tripRepo, reminderRepo, yandexAPI, cfg.Telegram.AdminIDs)
+	calendarUC := usecase.NewCalendarUsecase(tripRepo, userRepo, settingsRepo, cfg.HTTP.PublicURL)
+	dataUC := usecase.NewDataExportUsecase(tripRepo, reminderRepo, bookRepo, settingsRepo)
+
+	botWrapped := telegram.NewBot(nil, tripUC, bookUC, userUC, settingsUC, reminderUC, adminUC, calendarUC, dataUC)
+
+	opts := telegram.LoggingOptions()
+	if cfg.Telegram.Mode == config.ModeWebhook {
+		opts = append(opts, bot.WithWebhookSecretToken(cfg.Telegram.Webhook.Secret))
+	}
+
+	botClient, err := bot.New(cfg.Telegram.Token, opts...)
+	if err != nil {
+		return err
+	}
+
+	reminderWorker := worker.NewWorker(tripUC, bookUC, userUC, reminderUC, reminderRepo, postgres.NewReminderListener(pool), botWrapped, worker.Config{
+		PollInterval: cfg.Worker.Interval,
+		Concurrency:  cfg.Worker.Concurrency,
+	})
+
+	botWrapped.AddClient(botClient)
+	botWrapped.SetSessionTTL(cfg.Cache.SessionTTL)
+	botWrapped.SetDispatcherConfig(telegram.DispatcherConfig{
+		GlobalRate:   cfg.Telegram.Outbound.GlobalRate,
+		ChatInterval: cfg.Telegram.Outbound.ChatInterval,
+		QueueSize:    cfg.Telegram.Outbound.QueueSize,
+		MaxRetries:   cfg.Telegram.Outbound.MaxRetries,
+	})
+	botWrapped.RegisterHandlers()
+
+	metrics.RegisterSessions(botWrapped.SessionCount)
+	metrics.RegisterOutboundQueue(botWrapped.Dispatcher().Len)
+	metrics.RegisterReminderStatus(reminderRepo.CountByStatus)
+
+	// The webhook and calendar feeds may share the operational server if
+	// they use its address
+	var servers []*httpapi.Server
+	server := func(addr string) *httpapi.Server {
+		for _, s := range servers {
+			if s.Addr() == addr {
+				return s
+			}
+		}
+		s := httpapi.NewServer(addr)
+		servers = append(servers, s)
+		return s
+	}
+	if cfg.HTTP.Addr != "" {
+		s := server(cfg.HTTP.Addr)
+		s.Handle("/metrics", promhttp.Handler())
+		s.HandleHealth(healthChecks(pool, botWrapped, reminderWorker, breaker)...)
+	}
+	// Feeds are public, so they get their own server unless CALENDAR_ADDR is unset
+	if calendarUC.LinksEnabled() {
+		server(cfg.HTTP.CalendarListenAddr()).HandleCalendar(usecase.CalendarPathPrefix, calendarUC.ExportByToken)
+	}
+	if cfg.Telegram.Mode == config.ModeWebhook {
+		server(cfg.Telegram.Webhook.Addr).Handle(cfg.Telegram.Webhook.Path, botWrapped.WebhookHandler(cfg.Telegram.Webhook.Secret))
+	}
+
+	// Started in this order and stopped in reverse: the bot stops taking
+	// updates first, then the worker drains in-flight reminders, then the
+	// dispatcher flushes what they queued and HTTP goes down; the pool is
+	// closed by the deferred pool.Close afterwards
+	app := lifecycle.NewManager(cfg.ShutdownTimeout)
+	for _, s := range servers {
+		app.Add(lifecycle.Component{Name: "http server " + s.Addr(), Start: s.Start, Stop: s.Stop})
+	}
+	app.Add(lifecycle.Background("telegram dispatcher", botWrapped.Dispatcher().Run))
+	app.Add(lifecycle.Component{
+		Name: "reminder worker",
+		Start: func(ctx context.Context) error {
+			reminderWorker.StartPolling(context.WithoutCancel(ctx), cfg.Worker.Pollers)
+			return nil
+		},
+		Stop: reminderWorker.Stop,
+	})
+	if cfg.Telegram.Mode == config.ModeWebhook {
+		app.Add(webhookComponent(botWrapped, cfg.Telegram.Webhook))
+	} else {
+		app.Add(pollingComponent(botWrapped))
+	}
+
+	// A component that failed to start or stop must fail the process, so
+	// the supervisor doesn't take it for a clean exit
+	if err := app.Run(ctx); err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
+
+// pollingComponent removes a webhook left over from a webhook deployment,
+// otherwise getUpdates is rejected by Telegram
+func pollingComponent(b *telegram.Bot) lifecycle.Component {
+	c := lifecycle.Background("telegram bot", b.Start)
+	start := c.Start
+	c.Start = func(ctx context.Context) error {
+		if err := b.DeleteWebhook(ctx); err != nil {
+			return err
+		}
+		return start(ctx)
+	}
+	return c
+}
+
+// webhookComponent registers the webhook once updates can be processed and
+// removes it on shutdown so Telegram keeps updates for the next instance
+func webhookComponent(b *telegram.Bot, cfg config.WebhookConfig) lifecycle.Component {
+	c := lifecycle.Background("telegram webhook", b.StartWebhook)
+	start, stop := c.Start, c.Stop
+	c.Start = func(ctx context.Context) error {
+		if err := start(ctx); err != nil {
+			return err
+		}
+		if err := b.SetWebhook(ctx, cfg.URL(), cfg.Secret); err != nil {
+			stop(ctx)
+			return err
+		}
+		slog.Info("webhook registered", "url", cfg.URL())
+		return nil
+	}
+	c.Stop = func(ctx context.Context) error {
+		return errors.Join(b.DeleteWebhook(ctx), stop(ctx))
+	}
+	return c
+}
@@ -0,0 +1,423 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Requirement lists settings that only some commands need, e.g. migrations
+// run without a bot token
+type Requirement int
+
+const (
+	RequireTelegram Requirement = 1 << iota
+	RequireYandex
+
+	RequireAll = RequireTelegram | RequireYandex
+)
+
+type Config struct {
+	DB       DBConfig
+	Telegram TelegramConfig
+	Yandex   YandexConfig
+	Worker   WorkerConfig
+	Cache    CacheConfig
+	HTTP     HTTPConfig
+	Log      LogConfig
+
+	ShutdownTimeout time.Duration
+}
+
+type DBConfig struct {
+	// DSN takes precedence over the individual fields below
+	DSN      string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+type TelegramConfig struct {
+	Token   string
+	Mode    string
+	Webhook WebhookConfig
+	// AdminIDs are Telegram IDs allowed to use the admin commands
+	AdminIDs []int64
+	Outbound OutboundConfig
+}
+
+// OutboundConfig limits the messages the bot sends
+type OutboundConfig struct {
+	GlobalRate   int           // messages per second across all chats
+	ChatInterval time.Duration // gap between reminders or broadcasts to one chat
+	QueueSize    int           // per priority lane
+	MaxRetries   int           // resends after a 429
+}
+
+type WebhookConfig struct {
+	// PublicURL is where Telegram reaches the bot, e.g. the reverse proxy;
+	// Path is appended to it
+	PublicURL string
+	Addr      string
+	Path      string
+	Secret    string
+}
+
+// URL returns the address registered with setWebhook
+func (c WebhookConfig) URL() string {
+	return strings.TrimRight(c.PublicURL, "/") + c.Path
+}
+
+type YandexConfig struct {
+	APIKey  string
+	Timeout time.Duration
+	// The circuit breaker opens after BreakerThreshold consecutive failures
+	// and stays open for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type WorkerConfig struct {
+	// Interval is the fallback poll interval if no notification arrives
+	Interval    time.Duration
+	Pollers     int
+	Concurrency int
+}
+
+type CacheConfig struct {
+	ScheduleTTL time.Duration
+	SessionTTL  time.Duration // idle dialog sessions are evicted after it, at least minSessionTTL
+}
+
+// minSessionTTL keeps dialogs alive long enough to finish a booking; sessions
+// are swept every SessionTTL/2
+const minSessionTTL = time.Minute
+
+type HTTPConfig struct {
+	Addr string
+	// CalendarAddr serves calendar feeds apart from /metrics and the health
+	// checks; they share Addr if it is empty
+	CalendarAddr string
+	// PublicURL is where users reach the calendar address, e.g.
+	// https://bot.example.com; subscription links are built from it and
+	// disabled without it
+	PublicURL string
+}
+
+// CalendarListenAddr returns the address calendar feeds are served on
+func (c HTTPConfig) CalendarListenAddr() string {
+	if c.CalendarAddr != "" {
+		return c.CalendarAddr
+	}
+	return c.Addr
+}
+
+type LogConfig struct {
+	Level slog.Level
+	// Format is json or text
+	Format string
+}
+
+// ConnString returns the DSN, building it from the individual fields if needed
+func (c DBConfig) ConnString() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// Load reads configuration from an optional file, then .env, then the
+// environment; later sources override earlier ones. All missing or invalid
+// values are reported at once.
+func Load(path string, req Requirement) (*Config, error) {
+	values := map[string]string{}
+
+	if path != "" {
+		fileValues, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		merge(values, fileValues)
+	}
+
+	if dotenv, err := godotenv.Read(); err == nil {
+		merge(values, dotenv)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v
+		}
+	}
+
+	l := &loader{values: values}
+	cfg := &Config{
+		DB: DBConfig{
+			DSN:      l.string("DATABASE_URL", ""),
+			Host:     l.string("DB_HOST", "localhost"),
+			Port:     l.int("DB_PORT", 5432),
+			User:     l.string("DB_USER", "postgres"),
+			Password: l.string("DB_PASSWORD", "postgres"),
+			Name:     l.string("DB_NAME", "postgres"),
+			SSLMode:  l.string("DB_SSLMODE", "disable"),
+		},
+		Telegram: TelegramConfig{
+			Token:    l.string("BOT_TOKEN", l.string("TELEGRAM_BOT_TOKEN", "")),
+			Mode:     l.string("TELEGRAM_MODE", ModePolling),
+			AdminIDs: l.int64List("ADMIN_IDS"),
+			Webhook: WebhookConfig{
+				PublicURL: l.string("WEBHOOK_URL", ""),
+				Addr:      l.string("WEBHOOK_ADDR", ":8443"),
+				Path:      l.string("WEBHOOK_PATH", "/telegram/webhook"),
+				Secret:    l.string("WEBHOOK_SECRET", ""),
+			},
+			Outbound: OutboundConfig{
+				GlobalRate:   l.int("OUTBOUND_GLOBAL_RATE", 30),
+				ChatInterval: l.duration("OUTBOUND_CHAT_INTERVAL", time.Second),
+				QueueSize:    l.int("OUTBOUND_QUEUE_SIZE", 1000),
+				MaxRetries:   l.int("OUTBOUND_MAX_RETRIES", 3),
+			},
+		},
+		Yandex: YandexConfig{
+			// YANDEX_API is the old name from env.example
+			APIKey:  l.string("YANDEX_API_KEY", l.string("YANDEX_API", "")),
+			Timeout: l.duration("YANDEX_TIMEOUT", 10*time.Second),
+
+			BreakerThreshold: l.int("YANDEX_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  l.duration("YANDEX_BREAKER_COOLDOWN", 30*time.Second),
+		},
+		Worker: WorkerConfig{
+			Interval:    l.duration("WORKER_INTERVAL", time.Minute),
+			Pollers:     l.int("WORKER_POLLERS", 1),
+			Concurrency: l.int("WORKER_CONCURRENCY", 10),
+		},
+		Cache: CacheConfig{
+			ScheduleTTL: l.duration("SCHEDULE_CACHE_TTL", 5*time.Minute),
+			SessionTTL:  l.duration("SESSION_TTL", 24*time.Hour),
+		},
+		HTTP: HTTPConfig{
+			Addr:         l.string("HTTP_ADDR", ""),
+			CalendarAddr: l.string("CALENDAR_ADDR", ""),
+			PublicURL:    strings.TrimRight(l.string("PUBLIC_BASE_URL", ""), "/"),
+		},
+		Log: LogConfig{
+			Level:  l.level("LOG_LEVEL", slog.LevelInfo),
+			Format: l.string("LOG_FORMAT", "json"),
+		},
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+	}
+
+	cfg.validate(l, req)
+	if len(l.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(l.errs...))
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate(l *loader, req Requirement) {
+	if req&RequireTelegram != 0 && c.Telegram.Token == "" {
+		l.fail("BOT_TOKEN", "is required")
+	}
+	if req&RequireTelegram != 0 {
+		c.Telegram.validate(l)
+	}
+	if req&RequireYandex != 0 && c.Yandex.APIKey == "" {
+		l.fail("YANDEX_API_KEY", "is required")
+	}
+	if c.DB.DSN == "" && c.DB.Host == "" {
+		l.fail("DB_HOST", "is required when DATABASE_URL is not set")
+	}
+	if c.Worker.Interval <= 0 {
+		l.fail("WORKER_INTERVAL", "must be positive")
+	}
+	if c.Worker.Pollers < 1 {
+		l.fail("WORKER_POLLERS", "must be at least 1")
+	}
+	if c.Worker.Concurrency < 1 {
+		l.fail("WORKER_CONCURRENCY", "must be at least 1")
+	}
+	if c.Telegram.Outbound.GlobalRate < 1 {
+		l.fail("OUTBOUND_GLOBAL_RATE", "must be at least 1")
+	}
+	if c.Telegram.Outbound.ChatInterval < 0 {
+		l.fail("OUTBOUND_CHAT_INTERVAL", "must not be negative")
+	}
+	if c.Telegram.Outbound.QueueSize < 1 {
+		l.fail("OUTBOUND_QUEUE_SIZE", "must be at least 1")
+	}
+	if c.Telegram.Outbound.MaxRetries < 0 {
+		l.fail("OUTBOUND_MAX_RETRIES", "must not be negative")
+	}
+	if c.Cache.SessionTTL < minSessionTTL {
+		l.fail("SESSION_TTL", fmt.Sprintf("must be at least %s", minSessionTTL))
+	}
+	if c.Yandex.Timeout <= 0 {
+		l.fail("YANDEX_TIMEOUT", "must be positive")
+	}
+	if c.Yandex.BreakerThreshold < 1 {
+		l.fail("YANDEX_BREAKER_THRESHOLD", "must be at least 1")
+	}
+	if c.Yandex.BreakerCooldown <= 0 {
+		l.fail("YANDEX_BREAKER_COOLDOWN", "must be positive")
+	}
+	if c.HTTP.PublicURL != "" {
+		if u, err := url.Parse(c.HTTP.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.fail("PUBLIC_BASE_URL", fmt.Sprintf("must be an http(s) URL, got %q", c.HTTP.PublicURL))
+		}
+		if c.HTTP.CalendarListenAddr() == "" {
+			l.fail("PUBLIC_BASE_URL", "requires CALENDAR_ADDR or HTTP_ADDR")
+		}
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		l.fail("LOG_FORMAT", fmt.Sprintf("must be json or text, got %q", c.Log.Format))
+	}
+	if c.ShutdownTimeout <= 0 {
+		l.fail("SHUTDOWN_TIMEOUT", "must be positive")
+	}
+}
+
+func (c TelegramConfig) validate(l *loader) {
+	switch c.Mode {
+	case ModePolling:
+		return
+	case ModeWebhook:
+	default:
+		l.fail("TELEGRAM_MODE", fmt.Sprintf("must be %s or %s, got %q", ModePolling, ModeWebhook, c.Mode))
+		return
+	}
+
+	if u, err := url.Parse(c.Webhook.PublicURL); c.Webhook.PublicURL == "" {
+		l.fail("WEBHOOK_URL", "is required in webhook mode")
+	} else if err != nil || u.Scheme != "https" || u.Host == "" {
+		l.fail("WEBHOOK_URL", fmt.Sprintf("must be an https URL, got %q", c.Webhook.PublicURL))
+	}
+	if !strings.HasPrefix(c.Webhook.Path, "/") {
+		l.fail("WEBHOOK_PATH", "must start with /")
+	}
+	// Telegram accepts 1-256 characters A-Z, a-z, 0-9, _ and -
+	if n := len(c.Webhook.Secret); n == 0 {
+		l.fail("WEBHOOK_SECRET", "is required in webhook mode")
+	} else if n > 256 || strings.TrimFunc(c.Webhook.Secret, isSecretRune) != "" {
+		l.fail("WEBHOOK_SECRET", "must be up to 256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+}
+
+func isSecretRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+func merge(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// loader reads typed values and collects every problem instead of stopping
+// at the first one
+type loader struct {
+	values map[string]string
+	errs   []error
+}
+
+func (l *loader) fail(key, msg string) {
+	l.errs = append(l.errs, fmt.Errorf("%s %s", key, msg))
+}
+
+func (l *loader) lookup(key string) (string, bool) {
+	if v := strings.TrimSpace(l.values[key]); v != "" {
+		return v, true
+	}
+	return "", false
+}
+
+func (l *loader) string(key, def string) string {
+	if v, ok := l.lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+func (l *loader) int(key string, def int) int {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.fail(key, fmt.Sprintf("must be an integer, got %q", v))
+		return def
+	}
+	return n
+}
+
+// int64List parses a comma separated list
+func (l *loader) int64List(key string) []int64 {
+	v, ok := l.lookup(key)
+	if !ok {
+		return nil
+	}
+	var list []int64
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			l.fail(key, fmt.Sprintf("must be a comma separated list of integers, got %q", part))
+			return nil
+		}
+		list = append(list, n)
+	}
+	return list
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.fail(key, fmt.Sprintf("must be a duration like 30s or 5m, got %q", v))
+		return def
+	}
+	return d
+}
+
+func (l *loader) level(key string, def slog.Level) slog.Level {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(v)); err != nil {
+		l.fail(key, fmt.Sprintf("must be one of debug, info, warn, error, got %q", v))
+		return def
+	}
+	return lvl
+}
@@ -0,0 +1,111 @@
+// Package calendar renders events as an iCalendar (RFC 5545) feed
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of the generated feed
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID      = "-//TravelScheduler//Trips//RU"
+	dateTime    = "20060102T150405Z"
+	maxLineSize = 75 // octets, without the line break
+)
+
+// Event is one VEVENT. End and Alarm are optional.
+type Event struct {
+	UID         string // globally unique, stable across exports
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	// Alarm shows a reminder this long before Start
+	Alarm time.Duration
+}
+
+// Write renders the events as a VCALENDAR named name; now is the DTSTAMP
+func Write(w io.Writer, name string, events []Event, now time.Time) error {
+	cw := &contentWriter{w: bufio.NewWriter(w)}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", prodID)
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	cw.line("X-WR-CALNAME", escapeText(name))
+
+	stamp := now.UTC().Format(dateTime)
+	for _, e := range events {
+		cw.line("BEGIN", "VEVENT")
+		cw.line("UID", escapeText(e.UID))
+		cw.line("DTSTAMP", stamp)
+		cw.line("DTSTART", e.Start.UTC().Format(dateTime))
+		if !e.End.IsZero() && e.End.After(e.Start) {
+			cw.line("DTEND", e.End.UTC().Format(dateTime))
+		}
+		cw.line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			cw.line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Location != "" {
+			cw.line("LOCATION", escapeText(e.Location))
+		}
+		if e.Alarm > 0 {
+			cw.line("BEGIN", "VALARM")
+			cw.line("ACTION", "DISPLAY")
+			cw.line("DESCRIPTION", escapeText(e.Summary))
+			cw.line("TRIGGER", fmt.Sprintf("-PT%dM", int(e.Alarm.Minutes())))
+			cw.line("END", "VALARM")
+		}
+		cw.line("END", "VEVENT")
+	}
+	cw.line("END", "VCALENDAR")
+
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// contentWriter writes CRLF-terminated content lines folded at 75 octets
+type contentWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (c *contentWriter) line(name, value string) {
+	if c.err != nil {
+		return
+	}
+	line := name + ":" + value
+	limit := maxLineSize
+	for len(line) > limit {
+		// Never split a UTF-8 sequence; continuation lines start with a
+		// space, which counts towards their length
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		_, c.err = c.w.WriteString(line[:cut] + "\r\n ")
+		if c.err != nil {
+			return
+		}
+		line = line[cut:]
+		limit = maxLineSize - 1
+	}
+	_, c.err = c.w.WriteString(line + "\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes a TEXT property value
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
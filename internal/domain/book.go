@@ -2,16 +2,16 @@ package domain
 
 import (
 	"context"
-	"errors"
 )
 
 var (
-	ErrBookAlreadyExists = errors.New("Книга с такими параметрами уже существует")
-	ErrBookNotFound      = errors.New("Книга не найдена")
-	ErrUserIsNotOwner    = errors.New("У вас нет прав для редактирования этой книги")
-	ErrPagesOverall      = errors.New("Превышено общее количество страниц")
-	ErrPagesMustNonZero  = errors.New("Количество страниц должно быть больше нуля")
-	ErrBookNameEmpty     = errors.New("Название книги не может быть пустым")
+	ErrBookAlreadyExists = NewError("book.exists", "book with these parameters already exists")
+	ErrBookNotFound      = NewError("book.not_found", "book not found")
+	ErrUserIsNotOwner    = NewError("book.not_owner", "book belongs to another user")
+	ErrPagesOverall      = NewError("book.pages_overall", "current page exceeds total pages")
+	ErrPagesMustNonZero  = NewError("book.pages_non_positive", "page count must be positive")
+	ErrBookNameEmpty     = NewError("book.title_empty", "book title is empty")
+	ErrBookNameTooLong   = NewError("book.title_too_long", "book title is too long")
 )
 
 type Book struct {
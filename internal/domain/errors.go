@@ -0,0 +1,29 @@
+package domain
+
+import "errors"
+
+// Error is an error a user may be shown. The transport translates Code through
+// its message catalogs; the text is only for logs.
+type Error struct {
+	Code string
+	text string
+}
+
+// NewError defines a domain error. Codes are dotted, e.g. "trip.not_found".
+func NewError(code, text string) *Error {
+	return &Error{Code: code, text: text}
+}
+
+func (e *Error) Error() string {
+	return e.text
+}
+
+// ErrorCode returns the code of the first domain error in err's chain, or ""
+// for errors that must not be shown to users
+func ErrorCode(err error) string {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Code
+	}
+	return ""
+}
@@ -2,35 +2,88 @@ package domain
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
 type ReminderStatus string
 
 var (
-	StatusPending   ReminderStatus = "pending"
-	StatusSent      ReminderStatus = "sent"
-	StatusFailed    ReminderStatus = "failed"
-	StatusCancelled ReminderStatus = "cancelled"
+	StatusPending      ReminderStatus = "pending"
+	StatusProcessing   ReminderStatus = "processing" // claimed by a worker, see LockedUntil
+	StatusSent         ReminderStatus = "sent"
+	StatusFailed       ReminderStatus = "failed"
+	StatusCancelled    ReminderStatus = "cancelled"
+	StatusAcknowledged ReminderStatus = "acknowledged"
 )
 
 var (
-	ErrReminderAlreadyExists = errors.New("Уведомление с такими параметрами уже существует")
-	ErrReminderNotFound      = errors.New("Уведомление не найдено")
+	ErrReminderAlreadyExists = NewError("reminder.exists", "reminder with these parameters already exists")
+	ErrReminderNotFound      = NewError("reminder.not_found", "reminder not found")
+	ErrReminderNotSent       = NewError("reminder.not_sent", "reminder has not been sent yet")
+	ErrReminderLeaseLost     = NewError("reminder.lease_lost", "reminder lease expired before it was handled")
 )
 
 type Reminder struct {
 	ID        int64     `db:"id"`
-	TripID    int64     `db:"trip_id"`
+	TripID    int64     `db:"trip_id"` // 0 for reminders not bound to a trip
 	UserID    int64     `db:"user_id"`
 	Message   string    `db:"message"`
 	TriggerAt time.Time `db:"trgger_at"`
 	Status    string    `db:"status"`
+
+	Attempts      int        `db:"attempts"`
+	LastError     string     `db:"last_error"`
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+// Lease returns when the claim on the reminder expires, zero if it is not
+// claimed
+func (r *Reminder) Lease() time.Time {
+	if r.LockedUntil == nil {
+		return time.Time{}
+	}
+	return *r.LockedUntil
+}
+
+// IsTrip reports whether the reminder is about an upcoming trip
+func (r *Reminder) IsTrip() bool {
+	return r.TripID != 0
+}
+
+// Delivery describes how the worker should deliver a due reminder
+type Delivery struct {
+	Silent     bool      // send with notifications disabled
+	DeferUntil time.Time // non-zero if the reminder must be postponed
 }
 
 type ReminderRepository interface {
 	Create(ctx context.Context, reminder *Reminder) error
+	GetByID(ctx context.Context, id int64) (*Reminder, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*Reminder, error)
+	GetByStatus(ctx context.Context, status ReminderStatus, limit int) ([]*Reminder, error)
+	CountByStatus(ctx context.Context) (map[string]int, error)
 	GetPending(ctx context.Context, now time.Time) ([]*Reminder, error)
-	MarkAsSent(ctx context.Context, id int64) error
+	ClaimPending(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Reminder, error)
+	NextDueAt(ctx context.Context) (*time.Time, error)
+	// MarkAsSent, MarkAttemptFailed, MarkAsFailed and Postpone finish a
+	// claimed reminder. They return ErrReminderLeaseLost unless the reminder
+	// is still processing under lease, the LockedUntil ClaimPending returned.
+	MarkAsSent(ctx context.Context, id int64, lease time.Time) error
+	MarkAttemptFailed(ctx context.Context, id int64, lease time.Time, lastError string, nextAttemptAt time.Time) error
+	MarkAsFailed(ctx context.Context, id int64, lease time.Time, lastError string) error
+	Postpone(ctx context.Context, id int64, lease time.Time, triggerAt time.Time) error
+	// Release returns claimed reminders to pending right away, skipping the
+	// ones whose lease has changed; it returns how many were released
+	Release(ctx context.Context, reminders []*Reminder) (int64, error)
+	// MarkAsAcknowledged and Snooze change only a sent reminder of the user
+	// and return ErrReminderNotSent otherwise
+	MarkAsAcknowledged(ctx context.Context, id, userID int64) error
+	Snooze(ctx context.Context, id, userID int64, triggerAt time.Time) error
+	CancelPendingByUserID(ctx context.Context, userID int64) error
+	Reschedule(ctx context.Context, id int64, triggerAt time.Time) error
+	// RescheduleByTrip moves the user's undelivered reminders for the trip,
+	// pending, waiting for a retry or claimed, to triggerAt and returns how
+	// many were moved
+	RescheduleByTrip(ctx context.Context, tripID, userID int64, triggerAt time.Time) (int64, error)
 }
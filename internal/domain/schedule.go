@@ -2,12 +2,12 @@ package domain
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
 var (
-	ErrInvalidInput = errors.New("Неправильный ввод\\. Формат ввода: <откуда\\> <куда\\> <дата\\> <время в формате 15:36:01\\>")
+	ErrInvalidInput = NewError("schedule.invalid_input", "invalid schedule query")
+	ErrScheduleUnavailable = NewError("schedule.unavailable", "schedule is temporarily unavailable")
 )
 
 type Schedule struct {
@@ -16,6 +16,8 @@ type Schedule struct {
 	DepartureTime time.Time `json:"departure"`
 	ArrivalTime time.Time `json:"arrival"`
 	Duration float64 `json:"duration"`
+	From string // station codes the schedule was requested for
+	To string
 }
 
 type ScheduleProvider interface {
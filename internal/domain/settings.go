@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	DefaultReminderLead = 30 * time.Minute
+	DefaultLanguage     = "ru"
+	DefaultTimezone     = "Europe/Moscow"
+)
+
+var (
+	ErrSettingsNotFound    = NewError("settings.not_found", "settings not found")
+	ErrInvalidReminderLead = NewError("settings.invalid_reminder_lead", "reminder lead must be positive")
+	ErrInvalidLanguage     = NewError("settings.invalid_language", "unsupported language")
+	ErrInvalidTimezone     = NewError("settings.invalid_timezone", "unknown time zone")
+	ErrInvalidQuietHours   = NewError("settings.invalid_quiet_hours", "quiet hours must be within 0..23")
+)
+
+// SupportedLanguages lists languages the bot UI can be switched to
+var SupportedLanguages = []string{"ru", "en"}
+
+type UserSettings struct {
+	UserID              int64   `db:"user_id"`
+	ReminderLeadMinutes int     `db:"reminder_lead_minutes"`
+	Language            string  `db:"language"`
+	Timezone            string  `db:"timezone"`
+	HomeStation         *string `db:"home_station"`
+	WorkStation         *string `db:"work_station"`
+	QuietHoursStart     *int    `db:"quiet_hours_start"`
+	QuietHoursEnd       *int    `db:"quiet_hours_end"`
+	ShowDetails         bool    `db:"show_details"`
+}
+
+// DefaultUserSettings returns settings used for users who never opened /settings
+func DefaultUserSettings(userID int64) *UserSettings {
+	return &UserSettings{
+		UserID:              userID,
+		ReminderLeadMinutes: int(DefaultReminderLead / time.Minute),
+		Language:            DefaultLanguage,
+		Timezone:            DefaultTimezone,
+		ShowDetails:         true,
+	}
+}
+
+func (s *UserSettings) ReminderLead() time.Duration {
+	if s.ReminderLeadMinutes <= 0 {
+		return DefaultReminderLead
+	}
+	return time.Duration(s.ReminderLeadMinutes) * time.Minute
+}
+
+// Location returns the user's time zone, falling back to the default one
+func (s *UserSettings) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(DefaultTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func (s *UserSettings) HasQuietHours() bool {
+	return s.QuietHoursStart != nil && s.QuietHoursEnd != nil && *s.QuietHoursStart != *s.QuietHoursEnd
+}
+
+// InQuietHours reports whether t falls into the user's quiet hours
+func (s *UserSettings) InQuietHours(t time.Time) bool {
+	if !s.HasQuietHours() {
+		return false
+	}
+	hour := t.In(s.Location()).Hour()
+	start, end := *s.QuietHoursStart, *s.QuietHoursEnd
+	if start < end {
+		return hour >= start && hour < end
+	}
+	// Quiet hours span midnight, e.g. 23-7
+	return hour >= start || hour < end
+}
+
+// NextQuietHoursEnd returns the nearest end of quiet hours after t
+func (s *UserSettings) NextQuietHoursEnd(t time.Time) time.Time {
+	if !s.HasQuietHours() {
+		return t
+	}
+	local := t.In(s.Location())
+	end := time.Date(local.Year(), local.Month(), local.Day(), *s.QuietHoursEnd, 0, 0, 0, local.Location())
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+func (s *UserSettings) Validate() error {
+	if s.ReminderLeadMinutes <= 0 {
+		return ErrInvalidReminderLead
+	}
+	if !IsSupportedLanguage(s.Language) {
+		return ErrInvalidLanguage
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	if (s.QuietHoursStart == nil) != (s.QuietHoursEnd == nil) {
+		return ErrInvalidQuietHours
+	}
+	if s.QuietHoursStart != nil {
+		if *s.QuietHoursStart < 0 || *s.QuietHoursStart > 23 || *s.QuietHoursEnd < 0 || *s.QuietHoursEnd > 23 {
+			return ErrInvalidQuietHours
+		}
+	}
+	return nil
+}
+
+func IsSupportedLanguage(lang string) bool {
+	for _, l := range SupportedLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+type SettingsRepository interface {
+	GetByUserID(ctx context.Context, userID int64) (*UserSettings, error)
+	Upsert(ctx context.Context, settings *UserSettings) error
+}
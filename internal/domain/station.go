@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+)
+
+var (
+	ErrStationNotFound = NewError("station.not_found", "station not found")
+)
+
+type Station struct {
+	Code string `db:"code"`
+	Name string `db:"name"`
+}
+
+type StationRepository interface {
+	Upsert(ctx context.Context, stations []Station) (int, error)
+	GetByCode(ctx context.Context, code string) (*Station, error)
+	Search(ctx context.Context, query string, limit int) ([]Station, error)
+}
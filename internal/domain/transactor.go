@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// Transactor runs fn as one unit of work: repository calls made with the ctx
+// passed to fn are committed together or not at all
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,25 +2,137 @@ package domain
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
 var (
-	ErrTripAlreadyExists = errors.New("Поездка с такими параметрами уже существует")
-	ErrTripNotFound      = errors.New("Поездка не найдена")
+	ErrTripAlreadyExists = NewError("trip.exists", "trip with these parameters already exists")
+	ErrTripNotFound      = NewError("trip.not_found", "trip not found")
+	ErrTripAlreadyBooked = NewError("trip.already_booked", "user is already booked on this train")
+	ErrTripDeparted      = NewError("trip.departed", "train has already departed")
+	ErrTripOwnJoin       = NewError("trip.own_join", "user joins their own trip")
+	ErrTripAlreadyJoined = NewError("trip.already_joined", "user already joined this trip")
+	ErrTripRouteMismatch = NewError("trip.route_mismatch", "train runs on another route than the trip")
 )
 
 type Trip struct {
-	ID            int64     `db:"id"`      // trip id
-	UserID        int64     `db:"user_id"` // user id from database, whos traveling
-	From          string    `db:"from_station"`
-	To            string    `db:"to_station"`
-	BookID        *int64    `db:"book_id"`
-	DepartureTime time.Time `db:"departure_time"`
+	ID            int64         `db:"id"`      // trip id
+	UserID        int64         `db:"user_id"` // user id from database, whos traveling
+	From          string        `db:"from_station"`
+	To            string        `db:"to_station"`
+	FromName      string        `db:"from_name"`
+	ToName        string        `db:"to_name"`
+	TrainNumber   string        `db:"train_number"`
+	TrainTitle    string        `db:"train_title"`
+	BookID        *int64        `db:"book_id"`
+	DepartureTime time.Time     `db:"departure_time"`
+	ArrivalTime   time.Time     `db:"arrival_time"`     // zero if unknown
+	Duration      time.Duration `db:"duration_seconds"` // time on the train, 0 if unknown
+	RequestID     string        `db:"request_id"`       // confirmation that created the trip, empty if unknown
+	ChatID        int64         `db:"chat_id"`          // group chat the trip was booked in, 0 for private chats
+	CreatedAt     time.Time     `db:"created_at"`
+}
+
+// ApplySchedule copies the chosen train into the trip, so it can be shown
+// later without asking the schedule API again
+func (t *Trip) ApplySchedule(s *Schedule) {
+	t.TrainNumber = s.TrainID
+	t.TrainTitle = s.Title
+	t.DepartureTime = s.DepartureTime
+	t.ArrivalTime = s.ArrivalTime
+	t.Duration = time.Duration(s.Duration) * time.Second
+}
+
+// FromDisplay returns the departure station name, or its code for trips
+// saved before names were stored
+func (t *Trip) FromDisplay() string {
+	if t.FromName != "" {
+		return t.FromName
+	}
+	return t.From
+}
+
+// ToDisplay returns the arrival station name, or its code
+func (t *Trip) ToDisplay() string {
+	if t.ToName != "" {
+		return t.ToName
+	}
+	return t.To
+}
+
+// TripChange records a trip moved to another train; Old* is the booking
+// before the change
+type TripChange struct {
+	ID             int64
+	TripID         int64
+	UserID         int64
+	OldTrainNumber string
+	OldTrainTitle  string
+	OldDeparture   time.Time
+	OldArrival     time.Time // zero if unknown
+	NewTrainNumber string
+	NewTrainTitle  string
+	NewDeparture   time.Time
+	NewArrival     time.Time
+	ChangedAt      time.Time
+}
+
+// TripMonthStat is the number of trips departed in a month
+type TripMonthStat struct {
+	Month time.Time
+	Trips int
+}
+
+// RouteStat is how often a user took a route
+type RouteStat struct {
+	From     string
+	To       string
+	FromName string
+	ToName   string
+	Trips    int
+}
+
+// TripTotals sums up a user's past trips
+type TripTotals struct {
+	Trips     int
+	TrainTime time.Duration
+	PagesRead int // book progress saved during trips
 }
 
 type TripRepository interface {
 	Create(ctx context.Context, trip *Trip) error
+	GetByID(ctx context.Context, id int64) (*Trip, error)
+	// GetByIDForUpdate locks the trip until the transaction ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*Trip, error)
+	// GetByUserID returns the trips the user booked or joined
 	GetByUserID(ctx context.Context, userId int64) ([]*Trip, error)
+	GetByRequestID(ctx context.Context, requestID string) (*Trip, error)
+	// GetByTrain returns the user's trip on the train departing at departure
+	GetByTrain(ctx context.Context, userID int64, trainNumber string, departure time.Time) (*Trip, error)
+	CountCreatedSince(ctx context.Context, since time.Time) (int, error)
+	// GetUpcoming returns trips departing after now, soonest first
+	GetUpcoming(ctx context.Context, userID int64, now time.Time, limit, offset int) ([]*Trip, error)
+	// GetPast returns trips departed by now, most recent first
+	GetPast(ctx context.Context, userID int64, now time.Time, limit, offset int) ([]*Trip, error)
+	CountByUser(ctx context.Context, userID int64, now time.Time) (upcoming, past int, err error)
+	CountByMonth(ctx context.Context, userID int64, since, now time.Time, loc *time.Location) ([]TripMonthStat, error)
+	TopRoutes(ctx context.Context, userID int64, now time.Time, limit int) ([]RouteStat, error)
+	Totals(ctx context.Context, userID int64, now time.Time) (TripTotals, error)
+	// UpdateSchedule stores the train snapshot fields of trip
+	UpdateSchedule(ctx context.Context, trip *Trip) error
+	RecordChange(ctx context.Context, change *TripChange) error
+	// GetChanges returns the trip's changes, oldest first
+	GetChanges(ctx context.Context, tripID int64) ([]*TripChange, error)
+	// AddPagesRead credits pages of the book to the user's trip under way at
+	// now; false if there is none
+	AddPagesRead(ctx context.Context, userID, bookID int64, pages int, now time.Time, grace, fallback time.Duration) (bool, error)
+	// PagesReadByTrip returns the pages the user read on each trip, by trip ID
+	PagesReadByTrip(ctx context.Context, userID int64) (map[int64]int, error)
+	// AddParticipant joins the user to a group trip
+	AddParticipant(ctx context.Context, tripID, userID int64) error
+	// GetParticipants returns the users who joined the trip, in joining order
+	GetParticipants(ctx context.Context, tripID int64) ([]*User, error)
+	// HandOver gives the user's group trips to their companions before the
+	// user is deleted
+	HandOver(ctx context.Context, userID int64) error
 }
@@ -2,12 +2,12 @@ package domain
 
 import (
 	"context"
-	"errors"
 )
 
 var (
 	ErrUniqueViolation   = "23505"
-	ErrUserAlreadyExists = errors.New("Пользователь с таким telegram id уже существует")
+	ErrUserAlreadyExists = NewError("user.exists", "user with this telegram id already exists")
+	ErrUserNotFound      = NewError("user.not_found", "user not found")
 )
 
 type User struct {
@@ -15,10 +15,23 @@ type User struct {
 	TelegramID int64 `db:"telegram_id"`
 	Name       string `db:"name"`
 	Username   string `db:"username"`
+	IsActive   bool   `db:"is_active"` // false once the user blocked the bot
+	ChatID     int64  `db:"chat_id"`   // private chat with the bot, 0 if the user only wrote in groups
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByTelegramID(ctx context.Context, telegramID int64) (*User, error)
 	GetByID(ctx context.Context, userID int64) (*User, error)
+	SetActive(ctx context.Context, userID int64, active bool) error
+	SetChatID(ctx context.Context, userID, chatID int64) error
+	List(ctx context.Context) ([]*User, error)
+	Count(ctx context.Context) (total, active int, err error)
+	// Delete removes the user; trips, reminders, books and settings go with
+	// it through ON DELETE CASCADE
+	Delete(ctx context.Context, userID int64) error
+	// SetCalendarToken stores the calendar subscription secret, empty revokes it
+	SetCalendarToken(ctx context.Context, userID int64, token string) error
+	GetCalendarToken(ctx context.Context, userID int64) (string, error)
+	GetByCalendarToken(ctx context.Context, token string) (*User, error)
 }
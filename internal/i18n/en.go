@@ -0,0 +1,327 @@
+package i18n
+
+var enMessages = map[string]string{
+	// Common
+	"common.bad_params":      "Error: invalid parameters",
+	"common.bad_format":      "Invalid format",
+	"common.bad_page":        "Error: invalid page",
+	"common.user_error":      "Could not load your profile",
+	"common.unknown_command": "Unknown command",
+	"common.cancelled":       "Cancelled",
+	"common.saved":           "✓ Saved",
+	"common.searching":       "Searching the schedule...",
+	"common.no_previous":     "There is no previous step",
+	"common.state_error":     "Unexpected state",
+	"common.error":           "❌ %s",
+	"common.recoverable":     "⚠️ Error\n\n%s\n\nWhat next?",
+
+	"button.back":           "◀️ Back",
+	"button.cancel":         "❌ Cancel",
+	"button.retry":          "🔄 Try again",
+	"button.other_stations": "🔄 Other stations",
+	"button.edit":           "✏️ Change",
+	"button.other_date":     "📅 Another date",
+	"button.type_station":   "⌨️ Type a name",
+	"button.open_trip":      "🚆 Open trip #%d",
+	"button.join_trip":      "🙋 I'm coming too",
+	"button.all_trips":      "📋 All trips",
+
+	// Commands
+	"start.welcome": "👋 *Welcome to TravelPet\\!*\n\n" +
+		"I will help you plan your trips and remind you about them in advance\\.\n\n" +
+		"*Commands:*\n" +
+		"/newtrip — plan a new trip\n" +
+		"/mytrips — my trips\n" +
+		"/mystats — my statistics\n" +
+		"/export — trips in your calendar\n" +
+		"/mydata — data export and book import\n" +
+		"/settings — settings\n" +
+		"/language — bot language\n" +
+		"/privacy — data and privacy\n" +
+		"/help — help\n\n" +
+		"Ready to plan a trip? Tap /newtrip",
+	"help.text": "📖 *Commands*\n\n" +
+		"/newtrip — plan a new trip\n" +
+		"   The bot walks you through it step by step\n\n" +
+		"/mytrips — upcoming trips and history\n\n" +
+		"/mystats — trips by month, frequent routes, time on board and pages read\n\n" +
+		"/export — an \\.ics file with your upcoming trips for a calendar\n\n" +
+		"/mydata — export trips, reminders and books as CSV or JSON, import books from CSV and Goodreads\n\n" +
+		"/settings — reminder time, time zone, home station and more\n\n" +
+		"/language — change the bot language\n\n" +
+		"/privacy — what data the bot keeps\n\n" +
+		"/deleteme — delete your account and all data\n\n" +
+		"/help — show this help\n\n" +
+		"*How to plan a trip:*\n" +
+		"1\\. Tap /newtrip\n" +
+		"2\\. Enter the departure station \\(for example: s9613483 or Taganrog\\)\n" +
+		"3\\. Enter the destination station\n" +
+		"4\\. Pick a train from the schedule\n" +
+		"5\\. Done\\! The bot reminds you in advance \\(30 minutes by default, change it in /settings\\)\n\n" +
+		"*In a group chat:*\n" +
+		"Add the bot to a group and plan a trip with /newtrip — companions tap “🙋 I'm coming too” and get a reminder as well",
+	"text.fallback": "Use /newtrip to plan a trip\nFor help: /help",
+
+	// New trip
+	"trip.cancelled":        "❌ *Trip planning cancelled*\n\nStart again with /newtrip",
+	"trip.edit_cancelled":   "❌ *Trip change cancelled*\n\nThe trip stays as it was\\. Your trips: /mytrips",
+	"trip.select_from":      "📍 Choose the departure station\n\nPick a recent or popular one:",
+	"trip.select_to":        "📍 Choose the destination station\n\nPick a recent or popular one:",
+	"trip.input_from":       "⌨️ *Enter the name or code of the departure station*\n\nFor example: Taganrog or s9613483",
+	"trip.input_to":         "⌨️ *Enter the name or code of the destination station*\n\nFor example: Rostov\\-on\\-Don or s9612913",
+	"trip.pick_station":     "🔎 Several stations match “%s”\\. Pick the one you meant:",
+	"trip.station_no_match": "🔎 No station matches “%s”\\. Type another name or code:",
+	"trip.step_to": "✅ Departure station: *%s*\n\n" +
+		"Step 2 of 3: *Enter the destination station*\n\n" +
+		"You can enter:\n" +
+		"• A station code \\(for example: s9612913\\)\n" +
+		"• A station name \\(for example: Rostov\\-on\\-Don\\)\n\n" +
+		"_To cancel, send /cancel_",
+	"trip.no_trains":        "No trains found for this route.",
+	"trip.search_failed":    "Search failed: %s",
+	"trip.schedule_missing": "Error: the schedule was not found",
+	"trip.created": "✅ *Trip created\\!*\n\n" +
+		"📋 *Trip details:*\n%s\n" +
+		"I will remind you %s before departure\\. Have a nice trip\\! 🚂",
+	"trip.created_answer": "Trip created!",
+	"trip.joined":         "🙋 *%s* is coming on this train too",
+	"trip.joined_answer":  "You're in! I'll remind you before departure",
+	"trip.already_booked": "ℹ️ *You are already booked on this train*\n\n%s\n" +
+		"No new trip was created, the reminder is already scheduled\\.",
+	"trip.view_title": "🚆 *Trip \\#%d*\n\n",
+
+	"group.private_only":    "This command only works in a private chat with the bot",
+	"group.foreign_buttons": "These buttons belong to whoever ran the command. Run it yourself",
+
+	"schedule.header": "🚆 Train schedule\n\n📍 %s → %s\n\nPick a train:\n\n",
+	"schedule.train":  "🚆 Train: %s",
+
+	"details.train":    "🚆 Train: %s",
+	"details.route":    "📍 Route: *%s* → *%s*",
+	"details.duration": "⏱ Travel time: %s",
+
+	"duration.seconds":       "%d sec",
+	"duration.minutes":       "%d min",
+	"duration.hours":         "%dh (%d min)",
+	"duration.hours_minutes": "%dh %dm (%d min)",
+	"duration.total_days":    "%d d %d h %d min",
+	"duration.total_hours":   "%d h %d min",
+
+	"month.1":  "Jan",
+	"month.2":  "Feb",
+	"month.3":  "Mar",
+	"month.4":  "Apr",
+	"month.5":  "May",
+	"month.6":  "Jun",
+	"month.7":  "Jul",
+	"month.8":  "Aug",
+	"month.9":  "Sep",
+	"month.10": "Oct",
+	"month.11": "Nov",
+	"month.12": "Dec",
+
+	// Trip history and stats
+	"history.empty": "📋 *My trips*\n\n" +
+		"You have no planned trips yet\\.\n\n" +
+		"Plan one with /newtrip",
+	"history.upcoming_title": "📋 *Upcoming trips*\n\n",
+	"history.past_title":     "🗂 *Trip history*\n\n",
+	"history.upcoming_none":  "No planned trips\\. Plan a new one with /newtrip\n",
+	"history.past_none":      "No completed trips yet\\.\n",
+	"history.item":           "*%d\\.* Trip \\#%d\n",
+	"history.joined":         "   🙋 You joined this trip\n",
+	"history.page":           "Page %d of %d",
+	"history.tab_upcoming":   "📋 Upcoming (%d)",
+	"history.tab_past":       "🗂 History (%d)",
+	"history.load_failed":    "Could not load your trips",
+
+	"stats.title":      "📊 *My statistics*\n\n",
+	"stats.empty":      "No completed trips yet\\. Statistics will appear after your first trip\\.",
+	"stats.trips":      "🚆 Trips: *%d*\n",
+	"stats.train_time": "⏱ On board: *%s*\n",
+	"stats.pages":      "📖 Read on the way: *%d* pages\n",
+	"stats.by_month":   "\n*By month:*\n",
+	"stats.routes":     "\n*Frequent routes:*\n",
+
+	// Trip changes
+	"edit.title":       "✏️ *Changing trip \\#%d*\n\n",
+	"edit.pick_date":   "\nPick the date to show this route's trains for:",
+	"edit.today":       "Today",
+	"edit.tomorrow":    "Tomorrow",
+	"edit.failed":      "Could not change the trip, please try again later",
+	"edit.done":        "✅ *Trip \\#%d changed*\n\n%s\nThe reminder was moved: %s before the new departure\\.",
+	"edit.done_answer": "Trip changed!",
+
+	// Settings
+	"settings.title":          "⚙️ Settings\n\n",
+	"settings.lead":           "⏰ Reminder: %d min before",
+	"settings.language":       "🌐 Language: %s",
+	"settings.timezone":       "🕒 Time zone: %s",
+	"settings.home":           "🏠 Home: %s",
+	"settings.work":           "💼 Work: %s",
+	"settings.quiet":          "🌙 Quiet hours: %s",
+	"settings.details":        "ℹ️ Train details: %s",
+	"settings.button.lead":    "⏰ Reminder: %d min",
+	"settings.button.home":    "🏠 Home",
+	"settings.button.work":    "💼 Work",
+	"settings.button.details": "ℹ️ Details: %s",
+	"settings.lead_prompt":    "⏰ How many minutes before a trip should I remind you?",
+	"settings.lang_prompt":    "🌐 Choose a language",
+	"settings.tz_prompt":      "🕒 Choose a time zone",
+	"settings.home_prompt":    "🏠 Choose your home station",
+	"settings.work_prompt":    "💼 Choose your work station",
+	"settings.input_home":     "🏠 Type the name or code of your home station",
+	"settings.input_work":     "💼 Type the name or code of your work station",
+	"settings.quiet_prompt":   "🌙 Quiet hours\n\nDuring these hours reminders arrive silently and other notifications are postponed.",
+	"settings.reset":          "🗑 Reset",
+	"settings.quiet_off":      "🔔 Turn off",
+	"settings.unknown":        "Unknown setting",
+	"settings.load_failed":    "Could not load settings",
+	"settings.not_set":        "not set",
+	"settings.on":             "on",
+	"settings.off":            "off",
+
+	// Reminders
+	"reminder.snooze_5":       "⏰ Snooze 5 min",
+	"reminder.snooze_10":      "⏰ 10 min",
+	"reminder.ack":            "✅ Got it",
+	"reminder.snoozed":        "⏰ I will remind you again in %d min",
+	"reminder.snoozed_answer": "Snoozed",
+	"reminder.acked":          "✅ Acknowledged",
+	"reminder.book_finished":  "You finished the book %s",
+
+	// Calendar
+	"export.caption": "📅 Upcoming trips in the calendar: *%d*\n\n" +
+		"Open the file to add the trips to your calendar\\. The calendar alarm fires as early as the bot's reminder\\.",
+	"export.empty":       "📅 No upcoming trips, the calendar is empty\\. Plan a trip with /newtrip\\.",
+	"export.link_button": "🔗 Subscription link",
+	"export.link": "🔗 *Calendar subscription*\n\n" +
+		"Add this link to your calendar as a subscription by URL and new trips will show up there by themselves:\n\n" +
+		"`%s`\n\n" +
+		"⚠️ Anyone with the link can see your trips\\. If it ended up in the wrong hands, revoke it\\.",
+	"export.revoke_button":  "🚫 Revoke link",
+	"export.revoke_failed":  "Could not revoke the link, please try again later",
+	"export.revoked":        "🚫 The calendar link was revoked\\. Get a new one with /export\\.",
+	"export.revoked_answer": "Link revoked",
+
+	"calendar.name":      "TravelPet trips",
+	"calendar.train":     "Train: %s",
+	"calendar.route":     "Route: %s",
+	"calendar.departure": "Departure: %s",
+	"calendar.arrival":   "Arrival: %s",
+
+	// User data
+	"data.intro": "🗂 *My data*\n\n" +
+		"The export contains all your trips, reminders and books\\. " +
+		"CSV gives one file per table, JSON a single file\\.\n\n" +
+		"Books can be imported from CSV: use `books.csv` from an export or a Goodreads export\\.",
+	"data.export_csv":    "📄 Export CSV",
+	"data.export_json":   "🧾 Export JSON",
+	"data.import_button": "📥 Import books",
+	"data.export_failed": "Could not prepare the export, please try again later",
+	"data.export_ready":  "Export ready",
+	"data.file_caption":  "%s — rows: %d",
+	"data.import_prompt": "📥 *Book import*\n\n" +
+		"Send a CSV file as a document\\. It needs a header row with a title column " +
+		"\\(`name`, `title` or `Название`\\); author, page count and pages read are picked up too\\.\n\n" +
+		"_To cancel, send /cancel_",
+	"data.import_hint":       "To import books from a file, open /mydata and tap “Import books”.",
+	"data.import_need_csv":   "A CSV file is needed. Send it as a document or send /cancel.",
+	"data.download_failed":   "Could not get the file, please send it again.",
+	"data.import_title":      "📥 *Book import*\n\n",
+	"data.import_added":      "✅ Added: *%d*\n",
+	"data.import_duplicate":  "♻️ Already in your list: *%d*\n",
+	"data.import_skipped":    "⚠️ Rows skipped because of errors: *%d*\n",
+	"data.import_more":       "   … and %d more\n",
+	"data.import_row":        "   line %d: %s",
+	"data.import_aborted":    "❌ The import was cancelled because of an error, no books were added\\. Please try again later\\.",
+	"data.import_aborted_at": "❌ The import was cancelled because of an error in line %d, no books were added\\. Please try again later\\.",
+
+	// Privacy
+	"privacy.text": "🔒 *What data the bot keeps*\n\n" +
+		"• Telegram ID, name and username — to recognise you and send you messages\n" +
+		"• Trips: stations, train, departure and arrival times, change history\n" +
+		"• For trips from group chats: the group ID and the companions who tapped “I'm coming too”\n" +
+		"• Reminders and their delivery status\n" +
+		"• Books, reading progress and pages read on the way\n" +
+		"• Settings: time zone, language, home and work stations, quiet hours\n" +
+		"• The secret calendar link, if you requested one\n\n" +
+		"Yandex\\.Rasp only receives station codes and the search date, nothing about you\\. " +
+		"The bot's technical logs keep your Telegram ID and chat ID\\.\n\n" +
+		"*Managing your data:*\n" +
+		"/mydata — export all data as CSV or JSON\n" +
+		"/export — get or revoke the calendar link\n" +
+		"/deleteme — delete your account and all data permanently",
+	"delete.prompt": "⚠️ *Account deletion*\n\n" +
+		"All your trips, reminders, books and settings will be deleted and the calendar link will stop working\\. " +
+		"The data cannot be restored\\.\n\n" +
+		"If you need it, export it with /mydata first\\.",
+	"delete.confirm_button": "🗑 Yes, delete everything",
+	"delete.cancel_button":  "Cancel",
+	"delete.expired":        "The confirmation has expired, send /deleteme again",
+	"delete.already":        "The account is already deleted",
+	"delete.failed":         "Could not delete the account, please try again later",
+	"delete.done":           "✅ Your account and all data were deleted\\. To use the bot again, send /start\\.",
+	"delete.done_answer":    "Account deleted",
+	"delete.cancelled":      "Deletion cancelled, your data is untouched\\.",
+
+	// Errors, keyed by domain error code
+	"err.internal":                       "Something went wrong, please try again later",
+	"err.user.exists":                    "A user with this Telegram ID already exists",
+	"err.user.not_found":                 "User not found. Send /start",
+	"err.user.telegram_id_empty":         "Telegram ID must not be empty",
+	"err.book.exists":                    "This book is already in your list",
+	"err.book.not_found":                 "Book not found",
+	"err.book.not_owner":                 "You are not allowed to edit this book",
+	"err.book.pages_overall":             "That is more than the total number of pages",
+	"err.book.pages_non_positive":        "The page count must be greater than zero",
+	"err.book.title_empty":               "The book title must not be empty",
+	"err.book.title_too_long":            "The book title must be at most 255 characters",
+	"err.settings.not_found":             "Settings not found",
+	"err.settings.invalid_reminder_lead": "The reminder time must be greater than zero",
+	"err.settings.invalid_language":      "This language is not supported",
+	"err.settings.invalid_timezone":      "Unknown time zone",
+	"err.settings.invalid_quiet_hours":   "Quiet hours must be between 0 and 23",
+	"err.settings.unknown":               "Unknown setting",
+	"err.schedule.invalid_input":         "Invalid input. Format: <from> <to> <date> <time as 15:36:01>",
+	"err.schedule.unavailable":           "The schedule is temporarily unavailable, please try again later",
+	"err.reminder.exists":                "Such a reminder already exists",
+	"err.reminder.not_found":             "Reminder not found",
+	"err.reminder.not_sent":              "The reminder has not been sent yet",
+	"err.reminder.not_failed":            "The reminder is not in failed status",
+	"err.reminder.lease_lost":            "The reminder is being handled again",
+	"err.trip.exists":                    "Such a trip already exists",
+	"err.trip.not_found":                 "Trip not found",
+	"err.trip.already_booked":            "You are already booked on this train",
+	"err.trip.departed":                  "The train has already left, the trip can't be changed",
+	"err.trip.own_join":                  "This is your own trip, you're already going",
+	"err.trip.already_joined":            "You've already joined this trip",
+	"err.trip.route_mismatch":            "This train runs on another route, start over from /mytrips",
+	"err.trip.departure_time_empty":      "The departure time must not be empty",
+	"err.trip.to_platform_empty":         "The destination platform must not be empty",
+	"err.station.not_found":              "Station not found",
+	"err.calendar.links_disabled":        "Calendar subscription links are not configured",
+	"err.calendar.not_found":             "Calendar not found",
+	"err.export.unknown_format":          "Unknown export format",
+	"err.import.empty":                   "The file is empty",
+	"err.import.no_title":                "The file has no book title column (name, title or «Название»)",
+	"err.import.too_many":                "The file has too many rows",
+	"err.import.bad_number":              "The page count must be a number",
+	"err.import.bad_row":                 "The row could not be parsed",
+	"err.import.file_too_large":          "The file is too large, 1 MB at most",
+}
+
+var enPlurals = map[string]Plural{
+	"unit.minutes": {
+		One:  "%d minute",
+		Many: "%d minutes",
+	},
+	"trip.changed": {
+		One:  "\n✏️ Changed %d time, originally: %s\n",
+		Many: "\n✏️ Changed %d times, originally: %s\n",
+	},
+	"reminder.trip": {
+		One:  "Your trip from %s starts in %d minute! Don't be late!",
+		Many: "Your trip from %s starts in %d minutes! Don't be late!",
+	},
+}
@@ -0,0 +1,171 @@
+// Package i18n holds the bot's message catalogs.
+//
+// Messages sent with MarkdownV2 are stored already escaped; arguments are
+// escaped by the caller before they are passed to T or N.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Default is the catalog used for unknown languages and missing keys
+const Default = "ru"
+
+// Plural holds the forms of a message that depends on a count. Languages
+// without a separate few form leave it empty and get Many.
+type Plural struct {
+	One  string
+	Few  string
+	Many string
+}
+
+type catalog struct {
+	messages map[string]string
+	plurals  map[string]Plural
+	form     func(n int) int
+}
+
+var catalogs = map[string]*catalog{
+	"ru": {messages: ruMessages, plurals: ruPlurals, form: russianForm},
+	"en": {messages: enMessages, plurals: enPlurals, form: englishForm},
+}
+
+// Localizer renders messages in one language
+type Localizer struct {
+	lang string
+	cat  *catalog
+}
+
+// For returns the localizer for lang, falling back to Default
+func For(lang string) *Localizer {
+	cat, ok := catalogs[lang]
+	if !ok {
+		lang = Default
+		cat = catalogs[Default]
+	}
+	return &Localizer{lang: lang, cat: cat}
+}
+
+// Match maps a Telegram language_code like "en-US" to a catalog, "" if there
+// is none
+func Match(languageCode string) string {
+	lang := strings.ToLower(languageCode)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
+// Has reports whether key exists in this language or the default one
+func (l *Localizer) Has(key string) bool {
+	if _, ok := l.cat.messages[key]; ok {
+		return true
+	}
+	_, ok := catalogs[Default].messages[key]
+	return ok
+}
+
+// T returns the message for key formatted with args. Unknown keys are
+// returned as is so a gap shows up in the chat instead of an empty message.
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := l.cat.messages[key]
+	if !ok {
+		msg, ok = catalogs[Default].messages[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N returns the plural form of key for n. Without args the form is
+// formatted with n itself.
+func (l *Localizer) N(key string, n int, args ...any) string {
+	cat := l.cat
+	p, ok := cat.plurals[key]
+	if !ok {
+		cat = catalogs[Default]
+		p, ok = cat.plurals[key]
+	}
+	if !ok {
+		return key
+	}
+
+	msg := p.Many
+	switch cat.form(n) {
+	case formOne:
+		msg = p.One
+	case formFew:
+		if p.Few != "" {
+			msg = p.Few
+		}
+	}
+	if len(args) == 0 {
+		args = []any{n}
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Missing lists keys that some catalog lacks compared to the default one,
+// prefixed with the language
+func Missing() []string {
+	var missing []string
+	base := catalogs[Default]
+	for lang, cat := range catalogs {
+		if lang == Default {
+			continue
+		}
+		for key := range base.messages {
+			if _, ok := cat.messages[key]; !ok {
+				missing = append(missing, lang+":"+key)
+			}
+		}
+		for key := range base.plurals {
+			if _, ok := cat.plurals[key]; !ok {
+				missing = append(missing, lang+":"+key)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+const (
+	formOne = iota
+	formFew
+	formMany
+)
+
+// russianForm: 1, 21, 31 – one; 2-4, 22-24 – few; the rest, including 11-14 – many
+func russianForm(n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return formOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return formFew
+	default:
+		return formMany
+	}
+}
+
+func englishForm(n int) int {
+	if n == 1 || n == -1 {
+		return formOne
+	}
+	return formMany
+}
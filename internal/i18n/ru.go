@@ -0,0 +1,330 @@
+package i18n
+
+var ruMessages = map[string]string{
+	// Common
+	"common.bad_params":      "Ошибка: неверные параметры",
+	"common.bad_format":      "Ошибка формата",
+	"common.bad_page":        "Ошибка: неверная страница",
+	"common.user_error":      "Ошибка получения данных пользователя",
+	"common.unknown_command": "Неизвестная команда",
+	"common.cancelled":       "Отменено",
+	"common.saved":           "✓ Сохранено",
+	"common.searching":       "Поиск расписания...",
+	"common.no_previous":     "Нет предыдущего шага",
+	"common.state_error":     "Ошибка состояния",
+	"common.error":           "❌ %s",
+	"common.recoverable":     "⚠️ Ошибка\n\n%s\n\nЧто делать?",
+
+	"button.back":           "◀️ Назад",
+	"button.cancel":         "❌ Отменить",
+	"button.retry":          "🔄 Попробовать снова",
+	"button.other_stations": "🔄 Другие станции",
+	"button.edit":           "✏️ Изменить",
+	"button.other_date":     "📅 Другая дата",
+	"button.type_station":   "⌨️ Ввести название",
+	"button.open_trip":      "🚆 Открыть поездку #%d",
+	"button.join_trip":      "🙋 Я тоже еду",
+	"button.all_trips":      "📋 Все поездки",
+
+	// Commands
+	"start.welcome": "👋 *Добро пожаловать в TravelPet\\!*\n\n" +
+		"Я помогу вам планировать поездки и напомню о них заранее\\.\n\n" +
+		"*Доступные команды:*\n" +
+		"/newtrip — создать новую поездку\n" +
+		"/mytrips — мои поездки\n" +
+		"/mystats — моя статистика\n" +
+		"/export — поездки в календарь\n" +
+		"/mydata — выгрузка данных и импорт книг\n" +
+		"/settings — настройки\n" +
+		"/language — язык бота\n" +
+		"/privacy — данные и приватность\n" +
+		"/help — справка\n\n" +
+		"Начнем планировать поездку? Нажмите /newtrip",
+	"help.text": "📖 *Справка по командам*\n\n" +
+		"/newtrip — создать новую поездку\n" +
+		"   Бот проведет вас через пошаговый процесс создания поездки\n\n" +
+		"/mytrips — предстоящие поездки и история\n\n" +
+		"/mystats — поездки по месяцам, частые маршруты, время в пути и прочитанные страницы\n\n" +
+		"/export — файл \\.ics с предстоящими поездками для календаря\n\n" +
+		"/mydata — выгрузка поездок, напоминаний и книг в CSV или JSON, импорт книг из CSV и Goodreads\n\n" +
+		"/settings — время напоминаний, часовой пояс, домашняя станция и другое\n\n" +
+		"/language — сменить язык бота\n\n" +
+		"/privacy — какие данные хранит бот\n\n" +
+		"/deleteme — удалить аккаунт и все данные\n\n" +
+		"/help — показать эту справку\n\n" +
+		"*Как создать поездку:*\n" +
+		"1\\. Нажмите /newtrip\n" +
+		"2\\. Введите станцию отправления \\(например: s9613483 или Таганрог\\)\n" +
+		"3\\. Введите станцию назначения\n" +
+		"4\\. Выберите поезд из предложенного расписания\n" +
+		"5\\. Готово\\! Бот напомнит вам заранее \\(по умолчанию за 30 минут, можно изменить в /settings\\)\n\n" +
+		"*В групповом чате:*\n" +
+		"Добавьте бота в группу и создайте поездку через /newtrip — попутчики нажмут «🙋 Я тоже еду» и тоже получат напоминание",
+	"text.fallback": "Для начала создания поездки используйте команду /newtrip\nДля справки: /help",
+
+	// New trip
+	"trip.cancelled":        "❌ *Создание поездки отменено*\n\nВы можете начать заново командой /newtrip",
+	"trip.edit_cancelled":   "❌ *Изменение поездки отменено*\n\nПоездка осталась без изменений\\. Список поездок: /mytrips",
+	"trip.select_from":      "📍 Выберите станцию отправления\n\nВыберите из недавних или популярных:",
+	"trip.select_to":        "📍 Выберите станцию назначения\n\nВыберите из недавних или популярных:",
+	"trip.input_from":       "⌨️ *Введите название или код станции отправления*\n\nНапример: Таганрог или s9613483",
+	"trip.input_to":         "⌨️ *Введите название или код станции назначения*\n\nНапример: Ростов\\-на\\-Дону или s9612913",
+	"trip.pick_station":     "🔎 Нашлось несколько станций по запросу «%s»\\. Выберите нужную:",
+	"trip.station_no_match": "🔎 Станция «%s» не найдена\\. Введите другое название или код:",
+	"trip.step_to": "✅ Станция отправления: *%s*\n\n" +
+		"Шаг 2 из 3: *Введите станцию назначения*\n\n" +
+		"Вы можете ввести:\n" +
+		"• Код станции \\(например: s9612913\\)\n" +
+		"• Название станции \\(например: Ростов\\-на\\-Дону\\)\n\n" +
+		"_Для отмены введите /cancel_",
+	"trip.no_trains":        "Рейсы не найдены для этого маршрута.",
+	"trip.search_failed":    "Ошибка поиска: %s",
+	"trip.schedule_missing": "Ошибка: расписание не найдено",
+	"trip.created": "✅ *Поездка успешно создана\\!*\n\n" +
+		"📋 *Детали поездки:*\n%s\n" +
+		"Я напомню вам за %s до отправления\\. Приятной поездки\\! 🚂",
+	"trip.created_answer": "Поездка создана!",
+	"trip.joined":         "🙋 *%s* тоже едет этим поездом",
+	"trip.joined_answer":  "Вы тоже едете! Напомню перед отправлением",
+	"trip.already_booked": "ℹ️ *Вы уже записаны на этот поезд*\n\n%s\n" +
+		"Повторно поездка не создана, напоминание уже запланировано\\.",
+	"trip.view_title": "🚆 *Поездка \\#%d*\n\n",
+
+	"group.private_only":    "Эта команда работает только в личном чате с ботом",
+	"group.foreign_buttons": "Эти кнопки для участника, который вызвал команду. Вызовите ее сами",
+
+	"schedule.header": "🚆 Расписание рейсов\n\n📍 %s → %s\n\nВыберите поезд:\n\n",
+	"schedule.train":  "🚆 Поезд: %s",
+
+	"details.train":    "🚆 Поезд: %s",
+	"details.route":    "📍 Маршрут: *%s* → *%s*",
+	"details.duration": "⏱ В пути: %s",
+
+	"duration.seconds":       "%d сек",
+	"duration.minutes":       "%d мин",
+	"duration.hours":         "%dч (%d мин)",
+	"duration.hours_minutes": "%dч %dм (%d мин)",
+	"duration.total_days":    "%d д %d ч %d мин",
+	"duration.total_hours":   "%d ч %d мин",
+
+	"month.1":  "янв",
+	"month.2":  "фев",
+	"month.3":  "мар",
+	"month.4":  "апр",
+	"month.5":  "май",
+	"month.6":  "июн",
+	"month.7":  "июл",
+	"month.8":  "авг",
+	"month.9":  "сен",
+	"month.10": "окт",
+	"month.11": "ноя",
+	"month.12": "дек",
+
+	// Trip history and stats
+	"history.empty": "📋 *Мои поездки*\n\n" +
+		"У вас пока нет запланированных поездок\\.\n\n" +
+		"Создайте новую поездку командой /newtrip",
+	"history.upcoming_title": "📋 *Предстоящие поездки*\n\n",
+	"history.past_title":     "🗂 *История поездок*\n\n",
+	"history.upcoming_none":  "Запланированных поездок нет\\. Создайте новую командой /newtrip\n",
+	"history.past_none":      "Завершенных поездок пока нет\\.\n",
+	"history.item":           "*%d\\.* Поездка \\#%d\n",
+	"history.joined":         "   🙋 Вы едете попутчиком\n",
+	"history.page":           "Страница %d из %d",
+	"history.tab_upcoming":   "📋 Предстоящие (%d)",
+	"history.tab_past":       "🗂 История (%d)",
+	"history.load_failed":    "Не удалось загрузить поездки",
+
+	"stats.title":      "📊 *Моя статистика*\n\n",
+	"stats.empty":      "Завершенных поездок пока нет\\. Статистика появится после первой поездки\\.",
+	"stats.trips":      "🚆 Поездок: *%d*\n",
+	"stats.train_time": "⏱ В поезде: *%s*\n",
+	"stats.pages":      "📖 Прочитано в пути: *%d* стр\\.\n",
+	"stats.by_month":   "\n*По месяцам:*\n",
+	"stats.routes":     "\n*Частые маршруты:*\n",
+
+	// Trip changes
+	"edit.title":       "✏️ *Изменение поездки \\#%d*\n\n",
+	"edit.pick_date":   "\nВыберите дату, на которую показать поезда этого маршрута:",
+	"edit.today":       "Сегодня",
+	"edit.tomorrow":    "Завтра",
+	"edit.failed":      "Не удалось изменить поездку, попробуйте позже",
+	"edit.done":        "✅ *Поездка \\#%d изменена*\n\n%s\nНапоминание перенесено: за %s до нового отправления\\.",
+	"edit.done_answer": "Поездка изменена!",
+
+	// Settings
+	"settings.title":          "⚙️ Настройки\n\n",
+	"settings.lead":           "⏰ Напоминание: за %d мин",
+	"settings.language":       "🌐 Язык: %s",
+	"settings.timezone":       "🕒 Часовой пояс: %s",
+	"settings.home":           "🏠 Дом: %s",
+	"settings.work":           "💼 Работа: %s",
+	"settings.quiet":          "🌙 Тихие часы: %s",
+	"settings.details":        "ℹ️ Подробности рейсов: %s",
+	"settings.button.lead":    "⏰ Напоминание: %d мин",
+	"settings.button.home":    "🏠 Дом",
+	"settings.button.work":    "💼 Работа",
+	"settings.button.details": "ℹ️ Подробности: %s",
+	"settings.lead_prompt":    "⏰ За сколько минут напоминать о поездке?",
+	"settings.lang_prompt":    "🌐 Выберите язык",
+	"settings.tz_prompt":      "🕒 Выберите часовой пояс",
+	"settings.home_prompt":    "🏠 Выберите домашнюю станцию",
+	"settings.work_prompt":    "💼 Выберите станцию работы",
+	"settings.input_home":     "🏠 Введите название или код домашней станции",
+	"settings.input_work":     "💼 Введите название или код станции работы",
+	"settings.quiet_prompt":   "🌙 Тихие часы\n\nВ это время напоминания приходят без звука, а остальные уведомления откладываются.",
+	"settings.reset":          "🗑 Сбросить",
+	"settings.quiet_off":      "🔔 Выключить",
+	"settings.unknown":        "Неизвестная настройка",
+	"settings.load_failed":    "Ошибка загрузки настроек",
+	"settings.not_set":        "не задана",
+	"settings.on":             "вкл",
+	"settings.off":            "выкл",
+
+	// Reminders
+	"reminder.snooze_5":       "⏰ Отложить на 5 мин",
+	"reminder.snooze_10":      "⏰ 10 мин",
+	"reminder.ack":            "✅ Понятно",
+	"reminder.snoozed":        "⏰ Напомню еще раз через %d мин",
+	"reminder.snoozed_answer": "Отложено",
+	"reminder.acked":          "✅ Принято",
+	"reminder.book_finished":  "Вы закончили книгу %s",
+
+	// Calendar
+	"export.caption": "📅 Предстоящих поездок в календаре: *%d*\n\n" +
+		"Откройте файл, чтобы добавить поездки в календарь\\. Напоминание в календаре сработает за то же время, что и в боте\\.",
+	"export.empty":       "📅 Предстоящих поездок нет, календарь пуст\\. Создайте поездку через /newtrip\\.",
+	"export.link_button": "🔗 Ссылка для подписки",
+	"export.link": "🔗 *Подписка на календарь*\n\n" +
+		"Добавьте ссылку в календарь как подписку по URL, и новые поездки будут появляться в нем сами:\n\n" +
+		"`%s`\n\n" +
+		"⚠️ Любой, у кого есть ссылка, увидит ваши поездки\\. Если она попала не туда, отзовите ее\\.",
+	"export.revoke_button":  "🚫 Отозвать ссылку",
+	"export.revoke_failed":  "Не удалось отозвать ссылку, попробуйте позже",
+	"export.revoked":        "🚫 Ссылка на календарь отозвана\\. Новую можно получить через /export\\.",
+	"export.revoked_answer": "Ссылка отозвана",
+
+	"calendar.name":      "Поездки TravelPet",
+	"calendar.train":     "Поезд: %s",
+	"calendar.route":     "Маршрут: %s",
+	"calendar.departure": "Отправление: %s",
+	"calendar.arrival":   "Прибытие: %s",
+
+	// User data
+	"data.intro": "🗂 *Мои данные*\n\n" +
+		"Выгрузка содержит все поездки, напоминания и книги\\. " +
+		"CSV — отдельный файл на каждую таблицу, JSON — один файл\\.\n\n" +
+		"Книги можно загрузить из CSV: подойдет файл `books.csv` из выгрузки или экспорт Goodreads\\.",
+	"data.export_csv":    "📄 Выгрузить CSV",
+	"data.export_json":   "🧾 Выгрузить JSON",
+	"data.import_button": "📥 Импорт книг",
+	"data.export_failed": "Не удалось подготовить выгрузку, попробуйте позже",
+	"data.export_ready":  "Выгрузка готова",
+	"data.file_caption":  "%s — записей: %d",
+	"data.import_prompt": "📥 *Импорт книг*\n\n" +
+		"Отправьте CSV\\-файл документом\\. Нужна строка заголовков с колонкой названия " +
+		"\\(`name`, `title` или `Название`\\); также читаются автор, число страниц и прочитанные страницы\\.\n\n" +
+		"_Для отмены введите /cancel_",
+	"data.import_hint":       "Чтобы загрузить книги из файла, откройте /mydata и нажмите «Импорт книг».",
+	"data.import_need_csv":   "Нужен файл в формате CSV. Отправьте его документом или введите /cancel.",
+	"data.download_failed":   "Не удалось получить файл, попробуйте отправить его еще раз.",
+	"data.import_title":      "📥 *Импорт книг*\n\n",
+	"data.import_added":      "✅ Добавлено: *%d*\n",
+	"data.import_duplicate":  "♻️ Уже были в списке: *%d*\n",
+	"data.import_skipped":    "⚠️ Пропущено строк с ошибками: *%d*\n",
+	"data.import_more":       "   … и еще %d\n",
+	"data.import_row":        "   строка %d: %s",
+	"data.import_aborted":    "❌ Импорт отменен из\\-за ошибки, ни одна книга не добавлена\\. Попробуйте позже\\.",
+	"data.import_aborted_at": "❌ Импорт отменен из\\-за ошибки в строке %d, ни одна книга не добавлена\\. Попробуйте позже\\.",
+
+	// Privacy
+	"privacy.text": "🔒 *Какие данные хранит бот*\n\n" +
+		"• Telegram ID, имя и username — чтобы узнавать вас и отправлять сообщения\n" +
+		"• Поездки: станции, поезд, время отправления и прибытия, история изменений\n" +
+		"• Для поездок из групповых чатов — ID группы и список попутчиков, нажавших «Я тоже еду»\n" +
+		"• Напоминания и статус их доставки\n" +
+		"• Книги и прогресс чтения, страницы, прочитанные в пути\n" +
+		"• Настройки: часовой пояс, язык, домашняя и рабочая станции, тихие часы\n" +
+		"• Секретная ссылка на календарь, если вы ее получали\n\n" +
+		"В Яндекс\\.Расписания уходят только коды станций и дата поиска, без данных о вас\\. " +
+		"В технических логах бота остаются Telegram ID и ID чата\\.\n\n" +
+		"*Управление данными:*\n" +
+		"/mydata — выгрузить все данные в CSV или JSON\n" +
+		"/export — получить или отозвать ссылку на календарь\n" +
+		"/deleteme — удалить аккаунт и все данные без возможности восстановления",
+	"delete.prompt": "⚠️ *Удаление аккаунта*\n\n" +
+		"Будут удалены все ваши поездки, напоминания, книги и настройки, а ссылка на календарь перестанет работать\\. " +
+		"Восстановить данные будет невозможно\\.\n\n" +
+		"Если они нужны, сначала выгрузите их через /mydata\\.",
+	"delete.confirm_button": "🗑 Да, удалить все",
+	"delete.cancel_button":  "Отмена",
+	"delete.expired":        "Подтверждение устарело, отправьте /deleteme еще раз",
+	"delete.already":        "Аккаунт уже удален",
+	"delete.failed":         "Не удалось удалить аккаунт, попробуйте позже",
+	"delete.done":           "✅ Аккаунт и все данные удалены\\. Чтобы снова пользоваться ботом, отправьте /start\\.",
+	"delete.done_answer":    "Аккаунт удален",
+	"delete.cancelled":      "Удаление отменено, ваши данные на месте\\.",
+
+	// Errors, keyed by domain error code
+	"err.internal":                       "Что-то пошло не так, попробуйте позже",
+	"err.user.exists":                    "Пользователь с таким telegram id уже существует",
+	"err.user.not_found":                 "Пользователь не найден. Отправьте /start",
+	"err.user.telegram_id_empty":         "Telegram ID не может быть пустым",
+	"err.book.exists":                    "Книга с такими параметрами уже существует",
+	"err.book.not_found":                 "Книга не найдена",
+	"err.book.not_owner":                 "У вас нет прав для редактирования этой книги",
+	"err.book.pages_overall":             "Превышено общее количество страниц",
+	"err.book.pages_non_positive":        "Количество страниц должно быть больше нуля",
+	"err.book.title_empty":               "Название книги не может быть пустым",
+	"err.book.title_too_long":            "Название книги должно быть не длиннее 255 символов",
+	"err.settings.not_found":             "Настройки не найдены",
+	"err.settings.invalid_reminder_lead": "Время напоминания должно быть больше нуля",
+	"err.settings.invalid_language":      "Язык не поддерживается",
+	"err.settings.invalid_timezone":      "Неизвестный часовой пояс",
+	"err.settings.invalid_quiet_hours":   "Тихие часы должны быть в диапазоне от 0 до 23",
+	"err.settings.unknown":               "Неизвестная настройка",
+	"err.schedule.invalid_input":         "Неправильный ввод. Формат ввода: <откуда> <куда> <дата> <время в формате 15:36:01>",
+	"err.schedule.unavailable":           "Расписание временно недоступно, попробуйте позже",
+	"err.reminder.exists":                "Уведомление с такими параметрами уже существует",
+	"err.reminder.not_found":             "Уведомление не найдено",
+	"err.reminder.not_sent":              "Уведомление еще не отправлено",
+	"err.reminder.not_failed":            "Уведомление не в статусе failed",
+	"err.reminder.lease_lost":            "Уведомление уже обрабатывается повторно",
+	"err.trip.exists":                    "Поездка с такими параметрами уже существует",
+	"err.trip.not_found":                 "Поездка не найдена",
+	"err.trip.already_booked":            "Вы уже записаны на этот поезд",
+	"err.trip.departed":                  "Поезд уже отправился, поездку нельзя изменить",
+	"err.trip.own_join":                  "Это ваша поездка, вы уже едете",
+	"err.trip.already_joined":            "Вы уже присоединились к этой поездке",
+	"err.trip.route_mismatch":            "Этот поезд идет по другому маршруту, начните заново через /mytrips",
+	"err.trip.departure_time_empty":      "Время отправления не может быть пустым",
+	"err.trip.to_platform_empty":         "Платформа назначения не может быть пустой",
+	"err.station.not_found":              "Станция не найдена",
+	"err.calendar.links_disabled":        "Ссылки для подписки на календарь не настроены",
+	"err.calendar.not_found":             "Календарь не найден",
+	"err.export.unknown_format":          "Неизвестный формат выгрузки",
+	"err.import.empty":                   "Файл пустой",
+	"err.import.no_title":                "В файле нет колонки с названием книги (name, title или «Название»)",
+	"err.import.too_many":                "Слишком много строк в файле",
+	"err.import.bad_number":              "Количество страниц должно быть числом",
+	"err.import.bad_row":                 "Строка не разобрана",
+	"err.import.file_too_large":          "Файл слишком большой, максимум 1 МБ",
+}
+
+var ruPlurals = map[string]Plural{
+	"unit.minutes": {
+		One:  "%d минуту",
+		Few:  "%d минуты",
+		Many: "%d минут",
+	},
+	"trip.changed": {
+		One:  "\n✏️ Изменена %d раз, изначально: %s\n",
+		Few:  "\n✏️ Изменена %d раза, изначально: %s\n",
+		Many: "\n✏️ Изменена %d раз, изначально: %s\n",
+	},
+	"reminder.trip": {
+		One:  "Ваша поездка со станции %s начнется через %d минуту! Не опоздайте!",
+		Few:  "Ваша поездка со станции %s начнется через %d минуты! Не опоздайте!",
+		Many: "Ваша поездка со станции %s начнется через %d минут! Не опоздайте!",
+	},
+}
@@ -0,0 +1,116 @@
+package yandex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerProvider stops calling the API after threshold consecutive
+// failures and fails fast with domain.ErrScheduleUnavailable for cooldown.
+// After that a single trial request decides whether to close again.
+type BreakerProvider struct {
+	provider  domain.ScheduleProvider
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial request is in flight
+}
+
+func NewBreakerProvider(provider domain.ScheduleProvider, threshold int, cooldown time.Duration) *BreakerProvider {
+	return &BreakerProvider{
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+func (b *BreakerProvider) GetNextTrains(ctx context.Context, from, to string, date time.Time) ([]*domain.Schedule, error) {
+	if !b.allow() {
+		return nil, domain.ErrScheduleUnavailable
+	}
+
+	schedules, err := b.provider.GetNextTrains(ctx, from, to, date)
+	b.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// State returns the current state and since when the breaker is open
+func (b *BreakerProvider) State() (BreakerState, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh(time.Now())
+	return b.state, b.openedAt
+}
+
+func (b *BreakerProvider) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+	}
+	return true
+}
+
+func (b *BreakerProvider) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	// The user going away is not the API's fault
+	if err != nil && errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	// Client errors such as an unknown station say nothing about the API's health
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code < 500 && statusErr.Code != 429 {
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// refresh moves an open breaker to half-open once the cooldown passed;
+// must be called with mu held
+func (b *BreakerProvider) refresh(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+}
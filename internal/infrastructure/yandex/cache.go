@@ -0,0 +1,73 @@
+package yandex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+type cacheKey struct {
+	from, to, date string
+}
+
+type cacheEntry struct {
+	schedules []*domain.Schedule
+	expiresAt time.Time
+}
+
+// CachedProvider keeps schedule search results for ttl so that paging back
+// and forth or several users on the same route don't hit the API each time
+type CachedProvider struct {
+	provider domain.ScheduleProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func NewCachedProvider(provider domain.ScheduleProvider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[cacheKey]cacheEntry),
+	}
+}
+
+func (c *CachedProvider) GetNextTrains(ctx context.Context, from, to string, date time.Time) ([]*domain.Schedule, error) {
+	if c.ttl <= 0 {
+		return c.provider.GetNextTrains(ctx, from, to, date)
+	}
+
+	key := cacheKey{from: from, to: to, date: date.Format("2006-01-02")}
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.schedules, nil
+	}
+
+	schedules, err := c.provider.GetNextTrains(ctx, from, to, date)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.evictExpired(now)
+	c.entries[key] = cacheEntry{schedules: schedules, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return schedules, nil
+}
+
+// evictExpired drops stale entries; must be called with mu held
+func (c *CachedProvider) evictExpired(now time.Time) {
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/metrics"
 )
 
 // rostov code - 9612913
@@ -33,15 +34,25 @@ type failderResponse struct {
  // TODO: implement failed
 }
 
+// StatusError is returned for non-200 API responses
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.Code)
+}
+
 type Client struct {
 	apiKey string
 	client *http.Client
+	stats  requestStats
 }
 
-func NewClient(apiKey string) *Client {
+func NewClient(apiKey string, timeout time.Duration) *Client {
 	return &Client{
 		apiKey: apiKey, 
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: &http.Client{Timeout: timeout},
 	}
 }
 
@@ -51,17 +62,21 @@ func (c *Client) GetNextTrains(ctx context.Context, from, to string, date time.T
 
 	url := fmt.Sprintf("https://api.rasp.yandex-net.ru/v3.0/search/?apikey=%s&format=json&transport_types=suburban&from=%s&to=%s&lang=ru_RU&page=1&date=%s", c.apiKey, from, to, date.Format("2006-01-02"))
 
-	log.Println(url)
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
+		metrics.ObserveYandexRequest("error", time.Since(start))
+		c.stats.record(start, true)
 		return nil, err
 	}
 	defer resp.Body.Close()
+	metrics.ObserveYandexRequest(strconv.Itoa(resp.StatusCode), time.Since(start))
+	c.stats.record(start, resp.StatusCode != http.StatusOK)
 	
 	if resp.StatusCode != http.StatusOK {
 		// TODO: return failed "text" 
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &StatusError{Code: resp.StatusCode}
 	}
 	var data yandexResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
@@ -76,8 +91,16 @@ func (c *Client) GetNextTrains(ctx context.Context, from, to string, date time.T
 			DepartureTime: s.DepartureTime,
 			ArrivalTime: s.ArrivalTime,
 			Duration: s.Duration,
+			From: from,
+			To: to,
 		})
 	}
 
 	return options, nil
-} 
\ No newline at end of file
+} 
+
+// RequestStats returns how many API requests were made within window and
+// how many of them failed; window is at most an hour
+func (c *Client) RequestStats(window time.Duration) (total, failures int) {
+	return c.stats.since(time.Now(), window)
+}
@@ -0,0 +1,50 @@
+package yandex
+
+import (
+	"sync"
+	"time"
+)
+
+const statsBuckets = 60
+
+// requestStats counts API requests and failures in per-minute buckets over
+// the last hour
+type requestStats struct {
+	mu      sync.Mutex
+	buckets [statsBuckets]statsBucket
+}
+
+type statsBucket struct {
+	minute          int64
+	total, failures int
+}
+
+func (s *requestStats) record(now time.Time, failed bool) {
+	minute := now.Unix() / 60
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &s.buckets[minute%statsBuckets]
+	if b.minute != minute {
+		*b = statsBucket{minute: minute}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+func (s *requestStats) since(now time.Time, window time.Duration) (total, failures int) {
+	from := now.Add(-window).Unix() / 60
+	to := now.Unix() / 60
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, b := range s.buckets {
+		if b.minute > from && b.minute <= to {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Component is a long-running part of the application.
+// Start must not block: background work is started in goroutines.
+// Stop must return once the component has finished or ctx is done.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were added and stops them in
+// reverse order, so dependants go down before what they depend on.
+type Manager struct {
+	components      []Component
+	shutdownTimeout time.Duration
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Run starts all components, blocks until ctx is done and then stops them
+// within the shutdown timeout. If a component fails to start, the ones that
+// already started are stopped and the start error is returned.
+func (m *Manager) Run(ctx context.Context) error {
+	started := 0
+	var startErr error
+	for _, c := range m.components {
+		slog.Info("starting component", "component", c.Name)
+		if err := c.Start(ctx); err != nil {
+			startErr = fmt.Errorf("start %s: %w", c.Name, err)
+			break
+		}
+		started++
+	}
+
+	if startErr == nil {
+		<-ctx.Done()
+		slog.Info("shutdown requested")
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.shutdownTimeout)
+	defer cancel()
+
+	var stopErr error
+	for i := started - 1; i >= 0; i-- {
+		c := m.components[i]
+		slog.Info("stopping component", "component", c.Name)
+		if err := c.Stop(stopCtx); err != nil {
+			slog.Error("stop component failed", "component", c.Name, "error", err)
+			stopErr = errors.Join(stopErr, fmt.Errorf("stop %s: %w", c.Name, err))
+		}
+	}
+
+	return errors.Join(startErr, stopErr)
+}
+
+// Background adapts a blocking run function to a Component. Stop cancels the
+// run context and waits for run to return.
+func Background(name string, run func(ctx context.Context)) Component {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			go func() {
+				defer close(done)
+				run(runCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,127 @@
+// Package logging configures log/slog for the application: JSON output,
+// redaction of credentials and a logger carried in the context so that
+// handlers log with per-update attributes.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = []string{"token", "apikey", "api_key", "password", "secret", "dsn"}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret registers a value, such as the bot token or API key, that is
+// replaced in every log message and string attribute. Errors from HTTP
+// clients embed request URLs, so redacting by key alone is not enough.
+func AddSecret(secret string) {
+	if len(secret) < 4 {
+		// too short to be a credential, replacing it would mangle logs
+		return
+	}
+	secretsMu.Lock()
+	secrets = append(secrets, secret)
+	secretsMu.Unlock()
+}
+
+// Redact replaces registered secrets in s
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// New returns a logger writing JSON, or text if format is "text"
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	}
+	var h slog.Handler
+	if format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&redactingHandler{Handler: h})
+}
+
+func replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		// errors and Stringers are rendered here so that secrets in them
+		// are redacted too
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(Redact(v.Error()))
+		case interface{ String() string }:
+			a.Value = slog.StringValue(Redact(v.String()))
+		}
+	}
+	return a
+}
+
+// redactingHandler redacts the message, which ReplaceAttr does not see
+type redactingHandler struct {
+	slog.Handler
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Message = Redact(r.Message)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &redactingHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying l
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// With returns a context whose logger has the given attributes added
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// FromContext returns the logger stored in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// Err is the attribute used for errors
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
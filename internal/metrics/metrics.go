@@ -0,0 +1,149 @@
+// Package metrics defines the Prometheus metrics exported on /metrics.
+// Metrics are registered in the default registry, which also carries the Go
+// runtime and process collectors.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "travelscheduler"
+
+var (
+	updatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_updates_total",
+		Help:      "Telegram updates handled, by kind (command, callback, text) and action.",
+	}, []string{"kind", "action"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "telegram_handler_duration_seconds",
+		Help:      "Time spent handling a Telegram update.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind", "action"})
+
+	yandexRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "yandex_requests_total",
+		Help:      "Requests to the Yandex.Rasp API by HTTP status; status is \"error\" if no response was received.",
+	}, []string{"status"})
+
+	yandexDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "yandex_request_duration_seconds",
+		Help:      "Latency of Yandex.Rasp API requests.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	reminderDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminder_deliveries_total",
+		Help:      "Reminder delivery outcomes: sent, deferred, retry or failed.",
+	}, []string{"result"})
+
+	reminderLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reminder_lag_seconds",
+		Help:      "Delay between a reminder's trigger time and its delivery.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800},
+	})
+
+	dbQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_queries_total",
+		Help:      "Database queries by operation, table and result.",
+	}, []string{"operation", "table", "result"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+)
+
+// Reminder delivery results
+const (
+	ReminderSent     = "sent"
+	ReminderDeferred = "deferred"
+	ReminderRetry    = "retry"
+	ReminderFailed   = "failed"
+)
+
+func ObserveUpdate(kind, action string, d time.Duration) {
+	updatesTotal.WithLabelValues(kind, action).Inc()
+	handlerDuration.WithLabelValues(kind, action).Observe(d.Seconds())
+}
+
+func ObserveYandexRequest(status string, d time.Duration) {
+	yandexRequests.WithLabelValues(status).Inc()
+	yandexDuration.Observe(d.Seconds())
+}
+
+func ObserveReminderDelivery(result string) {
+	reminderDeliveries.WithLabelValues(result).Inc()
+}
+
+// ObserveReminderSent records a delivery and how late it was
+func ObserveReminderSent(triggerAt, sentAt time.Time) {
+	reminderDeliveries.WithLabelValues(ReminderSent).Inc()
+	reminderLag.Observe(sentAt.Sub(triggerAt).Seconds())
+}
+
+// RegisterSessions exports the number of in-memory dialog sessions
+func RegisterSessions(count func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "telegram_active_sessions",
+		Help:      "Dialog sessions currently kept in memory.",
+	}, func() float64 { return float64(count()) })
+}
+
+// RegisterOutboundQueue exports the number of messages waiting in the
+// outbound dispatcher
+func RegisterOutboundQueue(length func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "telegram_outbound_queue",
+		Help:      "Outbound messages waiting for a send slot.",
+	}, func() float64 { return float64(length()) })
+}
+
+// RegisterReminderStatus exports the number of reminders per status, read
+// from the database on every scrape
+func RegisterReminderStatus(count func(ctx context.Context) (map[string]int, error)) {
+	prometheus.MustRegister(&reminderStatusCollector{count: count})
+}
+
+var reminderStatusDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "reminders"),
+	"Reminders in the database by status.",
+	[]string{"status"}, nil,
+)
+
+type reminderStatusCollector struct {
+	count func(ctx context.Context) (map[string]int, error)
+}
+
+func (c *reminderStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- reminderStatusDesc
+}
+
+func (c *reminderStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(reminderStatusDesc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(reminderStatusDesc, prometheus.GaugeValue, float64(n), status)
+	}
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterPool exports pgxpool statistics
+func RegisterPool(pool *pgxpool.Pool) {
+	prometheus.MustRegister(&poolCollector{pool: pool})
+}
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+var (
+	poolAcquiredConns   = poolDesc("acquired_connections", "Connections currently in use.")
+	poolIdleConns       = poolDesc("idle_connections", "Idle connections.")
+	poolTotalConns      = poolDesc("total_connections", "Open connections.")
+	poolMaxConns        = poolDesc("max_connections", "Maximum pool size.")
+	poolAcquireCount    = poolDesc("acquires_total", "Successful connection acquires.")
+	poolEmptyAcquire    = poolDesc("empty_acquires_total", "Acquires that had to wait for a connection.")
+	poolCanceledAcquire = poolDesc("canceled_acquires_total", "Acquires canceled by their context.")
+	poolAcquireDuration = poolDesc("acquire_duration_seconds_total", "Total time spent acquiring connections.")
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{poolAcquiredConns, poolIdleConns, poolTotalConns, poolMaxConns,
+		poolAcquireCount, poolEmptyAcquire, poolCanceledAcquire, poolAcquireDuration} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquire, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquire, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
+
+// QueryTracer measures every query the repositories run. Set it as
+// pgx.ConnConfig.Tracer.
+type QueryTracer struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	at               time.Time
+	operation, table string
+}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op, table := classifyQuery(data.SQL)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{at: time.Now(), operation: op, table: table})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	result := "ok"
+	if data.Err != nil {
+		result = "error"
+	}
+	dbQueries.WithLabelValues(start.operation, start.table, result).Inc()
+	dbQueryDuration.WithLabelValues(start.operation, start.table).Observe(time.Since(start.at).Seconds())
+}
+
+// classifyQuery returns the statement verb and the first table it names,
+// keeping label cardinality bounded by the schema
+func classifyQuery(sql string) (operation, table string) {
+	fields := strings.Fields(strings.ToLower(sql))
+	if len(fields) == 0 {
+		return "unknown", ""
+	}
+	operation = fields[0]
+
+	var after string
+	switch operation {
+	case "select", "delete":
+		after = "from"
+	case "insert":
+		after = "into"
+	case "update":
+		if len(fields) > 1 {
+			table = fields[1]
+		}
+	case "with":
+		// CTEs: use the verb of the main statement
+		for _, f := range fields[1:] {
+			if f == "update" || f == "insert" || f == "delete" {
+				operation = f
+			}
+		}
+	}
+	if after != "" {
+		for i, f := range fields[:len(fields)-1] {
+			if f == after {
+				table = fields[i+1]
+				break
+			}
+		}
+	}
+	return operation, strings.Trim(table, "(),;")
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,19 +19,19 @@ func NewBookRepository(db *pgxpool.Pool) *BookRepository {
 	}
 }
 
+// Create inserts book; a duplicate is skipped with ON CONFLICT rather than
+// failing, so it doesn't abort the transaction of an import
 func (b *BookRepository) Create(ctx context.Context, book *domain.Book) error {
-	query := `INSERT INTO books (user_id, book_name, total_pages, current_pages)
-						VALUES ($1, $2, $3, $4)
+	query := `INSERT INTO books (user_id, book_name, author, total_pages, current_pages)
+						VALUES ($1, $2, $3, $4, $5)
+						ON CONFLICT DO NOTHING
 						RETURNING id`
-	err := b.db.QueryRow(ctx, query, book.UserID, book.BookName, book.TotalPages, book.CurrentPages).Scan(&book.ID)
+	err := conn(ctx, b.db).QueryRow(ctx, query, book.UserID, book.BookName, book.Author, book.TotalPages, book.CurrentPages).Scan(&book.ID)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-    if errors.As(err, &pgErr) {
-        if pgErr.Code == domain.ErrUniqueViolation { 
-            return domain.ErrBookAlreadyExists 
-        }
-    }
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrBookAlreadyExists
+		}
 		return err 
 	}
 
@@ -39,8 +39,8 @@ func (b *BookRepository) Create(ctx context.Context, book *domain.Book) error {
 } 
 
 func (b *BookRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Book, error) {
-	query := `SELECT * FROM books WHERE user_id = $1`
-	rows, err := b.db.Query(ctx, query, userID)
+	query := `SELECT id, user_id, book_name, author, total_pages, current_pages FROM books WHERE user_id = $1 ORDER BY id`
+	rows, err := conn(ctx, b.db).Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -56,14 +56,17 @@ func (b *BookRepository) GetByUserID(ctx context.Context, userID int64) ([]*doma
 		books = append(books, book)
 	}
 
-	return books, nil
+	return books, rows.Err()
 }
 
 func (b *BookRepository) GetByID(ctx context.Context, bookID int64) (*domain.Book, error) {
 	query := `SELECT id, user_id, book_name, author, total_pages, current_pages FROM books WHERE id = $1`
 	book := &domain.Book{}
-	err := b.db.QueryRow(ctx, query, bookID).Scan(&book.ID, &book.UserID, &book.BookName, &book.Author, &book.TotalPages, &book.CurrentPages)
+	err := conn(ctx, b.db).QueryRow(ctx, query, bookID).Scan(&book.ID, &book.UserID, &book.BookName, &book.Author, &book.TotalPages, &book.CurrentPages)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrBookNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +76,7 @@ func (b *BookRepository) GetByID(ctx context.Context, bookID int64) (*domain.Boo
 
 func (b *BookRepository) UpdateProgress(ctx context.Context, bookID int64, currentPages int) error {
 	query := `UPDATE books SET current_pages = $2 WHERE id = $1` 
-	rows, err := b.db.Exec(ctx, query, bookID, currentPages)
+	rows, err := conn(ctx, b.db).Exec(ctx, query, bookID, currentPages)
 
 	if err != nil {
 		return err
@@ -88,7 +91,7 @@ func (b *BookRepository) UpdateProgress(ctx context.Context, bookID int64, curre
 
 func (b *BookRepository) Delete(ctx context.Context, bookID int64) error {
 	query := `DELETE FROM books WHERE id = $1`
-	_, err := b.db.Exec(ctx, query, bookID)
+	_, err := conn(ctx, b.db).Exec(ctx, query, bookID)
 	if err != nil {
 		return err
 	}
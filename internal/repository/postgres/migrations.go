@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -13,14 +14,84 @@ import (
 )
 
 func RunMigrations(dsn string) error {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if err == migrate.ErrNoChange {
+			return nil
+		}
+		return fmt.Errorf("cannot migrate up: %w", err)
+	}
+
+	slog.Info("migrations applied")
+
+	return nil
+}
+
+// RollbackMigrations reverts the last steps migrations
+func RollbackMigrations(dsn string, steps int) error {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil {
+		if err == migrate.ErrNoChange {
+			return nil
+		}
+		return fmt.Errorf("cannot migrate down: %w", err)
+	}
+
+	slog.Info("migrations rolled back", "steps", steps)
+
+	return nil
+}
+
+// MigrationVersion returns the current schema version; version is 0 if no
+// migration was applied yet
+func MigrationVersion(dsn string) (version uint, dirty bool, err error) {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err = m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// ForceMigrationVersion sets the version without running migrations, used
+// to recover from a dirty state after a failed migration was fixed by hand
+func ForceMigrationVersion(dsn string, version int) error {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("cannot force version %d: %w", version, err)
+	}
+	return nil
+}
+
+func newMigrate(dsn string) (*migrate.Migrate, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return fmt.Errorf("cannot connect to db: %w", err)
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
 	}
 	
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("cannot create driver: %w", err)
+		return nil, fmt.Errorf("cannot create driver: %w", err)
 	}
 	m, err := migrate.NewWithDatabaseInstance(
 		"file://migrations",
@@ -29,16 +100,7 @@ func RunMigrations(dsn string) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("cannot create migrate: %w", err)
+		return nil, fmt.Errorf("cannot create migrate: %w", err)
 	}
-	if err := m.Up(); err != nil {
-		if err == migrate.ErrNoChange {
-			return nil
-		}
-		return fmt.Errorf("cannot migrate up: %w", err)
-	}
-
-	slog.Info("migrations applied")
-
-	return nil
-}
\ No newline at end of file
+	return m, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reminderScheduledChannel is the NOTIFY channel used when a reminder is
+// created or rescheduled. The payload is its trigger_at in RFC 3339.
+const reminderScheduledChannel = "reminder_scheduled"
+
+const listenRetryDelay = 5 * time.Second
+
+// ReminderListener delivers reminder_scheduled notifications over a dedicated
+// connection taken from the pool
+type ReminderListener struct {
+	db *pgxpool.Pool
+}
+
+func NewReminderListener(db *pgxpool.Pool) *ReminderListener {
+	return &ReminderListener{
+		db: db,
+	}
+}
+
+// Run listens until ctx is done and calls notify with the trigger time of
+// every newly scheduled reminder. Lost connections are re-established.
+func (l *ReminderListener) Run(ctx context.Context, notify func(triggerAt time.Time)) {
+	for {
+		err := l.listen(ctx, notify)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("reminder listener stopped, reconnecting", "retry_in", listenRetryDelay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (l *ReminderListener) listen(ctx context.Context, notify func(triggerAt time.Time)) error {
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{reminderScheduledChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		triggerAt, err := time.Parse(time.RFC3339Nano, n.Payload)
+		if err != nil {
+			slog.Error("bad notification payload", "channel", reminderScheduledChannel, "payload", n.Payload, "error", err)
+			continue
+		}
+		notify(triggerAt)
+	}
+}
@@ -6,10 +6,15 @@ import (
 	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const reminderColumns = `r.id, COALESCE(r.trip_id, 0), r.user_id, r.message, r.trigger_at, r.status,
+						r.attempts, COALESCE(r.last_error, ''), r.next_attempt_at, r.locked_until`
+
 type ReminderRepository struct {
 	db *pgxpool.Pool
 }
@@ -24,7 +29,7 @@ func (r *ReminderRepository) Create(ctx context.Context, reminder *domain.Remind
 	query := `INSERT INTO reminders (trip_id, user_id, message, trigger_at, status)
 						VALUES ($1, $2, $3, $4, $5)
 						RETURNING id`
-	err := r.db.QueryRow(ctx, query, reminder.TripID, reminder.UserID, reminder.Message, reminder.TriggerAt, domain.StatusPending).Scan(&reminder.ID)
+	err := conn(ctx, r.db).QueryRow(ctx, query, nullableID(reminder.TripID), reminder.UserID, reminder.Message, reminder.TriggerAt, domain.StatusPending).Scan(&reminder.ID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -35,38 +40,308 @@ func (r *ReminderRepository) Create(ctx context.Context, reminder *domain.Remind
 		return err
 	}
 
+	r.notifyScheduled(ctx, reminder.TriggerAt)
+	return nil
+}
+
+func (r *ReminderRepository) MarkAsSent(ctx context.Context, id int64, lease time.Time) error {
+	query := `UPDATE reminders SET status = $1, attempts = attempts + 1, last_error = NULL, next_attempt_at = NULL, locked_until = NULL
+						WHERE id = $2 AND status = $3 AND locked_until = $4`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusSent, id, domain.StatusProcessing, lease)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderLeaseLost
+	}
 	return nil
 }
 
-func (r *ReminderRepository) MarkAsSent(ctx context.Context, id int64) error {
-	query := `UPDATE reminders SET status = $1 WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, domain.StatusSent, id)
+func (r *ReminderRepository) MarkAsAcknowledged(ctx context.Context, id, userID int64) error {
+	query := `UPDATE reminders SET status = $1 WHERE id = $2 AND user_id = $3 AND status = $4`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusAcknowledged, id, userID, domain.StatusSent)
 	if err != nil {
 		return err
 	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderNotSent
+	}
 	return nil
 }
 
+// MarkAttemptFailed records a failed delivery and schedules the next attempt
+func (r *ReminderRepository) MarkAttemptFailed(ctx context.Context, id int64, lease time.Time, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE reminders SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3, locked_until = NULL
+						WHERE id = $4 AND status = $5 AND locked_until = $6`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusPending, lastError, nextAttemptAt, id, domain.StatusProcessing, lease)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderLeaseLost
+	}
+	return nil
+}
+
+// MarkAsFailed moves the reminder to the dead-letter status
+func (r *ReminderRepository) MarkAsFailed(ctx context.Context, id int64, lease time.Time, lastError string) error {
+	query := `UPDATE reminders SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = NULL, locked_until = NULL
+						WHERE id = $3 AND status = $4 AND locked_until = $5`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusFailed, lastError, id, domain.StatusProcessing, lease)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderLeaseLost
+	}
+	return nil
+}
+
+// Postpone releases a claimed reminder until triggerAt without counting an
+// attempt, e.g. to wait for the end of quiet hours
+func (r *ReminderRepository) Postpone(ctx context.Context, id int64, lease time.Time, triggerAt time.Time) error {
+	query := `UPDATE reminders SET status = $1, trigger_at = $2, next_attempt_at = NULL, locked_until = NULL
+						WHERE id = $3 AND status = $4 AND locked_until = $5`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusPending, triggerAt, id, domain.StatusProcessing, lease)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderLeaseLost
+	}
+	r.notifyScheduled(ctx, triggerAt)
+	return nil
+}
+
+func (r *ReminderRepository) Release(ctx context.Context, reminders []*domain.Reminder) (int64, error) {
+	ids := make([]int64, 0, len(reminders))
+	leases := make([]time.Time, 0, len(reminders))
+	for _, rem := range reminders {
+		ids = append(ids, rem.ID)
+		leases = append(leases, rem.Lease())
+	}
+	query := `UPDATE reminders r SET status = $1, locked_until = NULL
+						FROM UNNEST($3::BIGINT[], $4::TIMESTAMPTZ[]) AS c(id, lease)
+						WHERE r.id = c.id AND r.status = $2 AND r.locked_until = c.lease`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusPending, domain.StatusProcessing, ids, leases)
+	if err != nil {
+		return 0, err
+	}
+	return rows.RowsAffected(), nil
+}
+
+func (r *ReminderRepository) CancelPendingByUserID(ctx context.Context, userID int64) error {
+	query := `UPDATE reminders SET status = $1 WHERE user_id = $2 AND status = $3`
+	_, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusCancelled, userID, domain.StatusPending)
+	return err
+}
+
+// Reschedule moves the reminder to triggerAt and makes it pending again
+func (r *ReminderRepository) Reschedule(ctx context.Context, id int64, triggerAt time.Time) error {
+	query := `UPDATE reminders SET status = $1, trigger_at = $2, attempts = 0, last_error = NULL, next_attempt_at = NULL,
+							locked_until = NULL
+						WHERE id = $3`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusPending, triggerAt, id)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderNotFound
+	}
+	r.notifyScheduled(ctx, triggerAt)
+	return nil
+}
+
+// Snooze makes the user's sent reminder pending again at triggerAt
+func (r *ReminderRepository) Snooze(ctx context.Context, id, userID int64, triggerAt time.Time) error {
+	query := `UPDATE reminders SET status = $1, trigger_at = $2, attempts = 0, last_error = NULL, next_attempt_at = NULL,
+							locked_until = NULL
+						WHERE id = $3 AND user_id = $4 AND status = $5`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, domain.StatusPending, triggerAt, id, userID, domain.StatusSent)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrReminderNotSent
+	}
+	r.notifyScheduled(ctx, triggerAt)
+	return nil
+}
+
+// RescheduleByTrip also takes back claimed reminders: the worker's result
+// is then dropped as a lost lease and the reminder fires at the new time
+func (r *ReminderRepository) RescheduleByTrip(ctx context.Context, tripID, userID int64, triggerAt time.Time) (int64, error) {
+	query := `UPDATE reminders SET status = $3, trigger_at = $5, attempts = 0, last_error = NULL, next_attempt_at = NULL,
+							locked_until = NULL
+							WHERE trip_id = $1 AND user_id = $2 AND status IN ($3, $4)`
+	rows, err := conn(ctx, r.db).Exec(ctx, query, tripID, userID, domain.StatusPending, domain.StatusProcessing, triggerAt)
+	if err != nil {
+		return 0, err
+	}
+	if rows.RowsAffected() == 0 {
+		return 0, nil
+	}
+	r.notifyScheduled(ctx, triggerAt)
+	return rows.RowsAffected(), nil
+}
+
+// NextDueAt returns when the earliest reminder becomes claimable, taking retry
+// backoff and leases of claimed reminders into account. Nil if there is none.
+// Both branches skip inactive users as ClaimPending does, otherwise their
+// reminders would look due forever and wake the workers in a loop.
+func (r *ReminderRepository) NextDueAt(ctx context.Context) (*time.Time, error) {
+	query := `SELECT MIN(due_at) FROM (
+							SELECT GREATEST(r.trigger_at, COALESCE(r.next_attempt_at, r.trigger_at)) AS due_at
+							FROM reminders r
+							JOIN users u ON u.id = r.user_id
+							WHERE r.status = $1 AND u.is_active
+							UNION ALL
+							SELECT r.locked_until
+							FROM reminders r
+							JOIN users u ON u.id = r.user_id
+							WHERE r.status = $2 AND u.is_active
+						) due`
+	var dueAt *time.Time
+	if err := conn(ctx, r.db).QueryRow(ctx, query, domain.StatusPending, domain.StatusProcessing).Scan(&dueAt); err != nil {
+		return nil, err
+	}
+	return dueAt, nil
+}
+
+// notifyScheduled wakes up listening workers; inside a transaction the
+// notification is delivered on commit. The reminder is already written, so a
+// failed notification is only logged: the workers' poll interval covers it.
+func (r *ReminderRepository) notifyScheduled(ctx context.Context, triggerAt time.Time) {
+	_, err := conn(ctx, r.db).Exec(ctx, `SELECT pg_notify($1, $2)`, reminderScheduledChannel, triggerAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		logging.FromContext(ctx).Warn("notify scheduled reminder failed", "trigger_at", triggerAt, logging.Err(err))
+	}
+}
+
+func (r *ReminderRepository) GetByID(ctx context.Context, id int64) (*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders r WHERE r.id = $1`
+	reminder, err := scanReminder(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrReminderNotFound
+		}
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func (r *ReminderRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders r WHERE r.user_id = $1 ORDER BY r.trigger_at`
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectReminders(rows)
+}
+
+// GetByStatus returns up to limit reminders with the status, most recent first
+func (r *ReminderRepository) GetByStatus(ctx context.Context, status domain.ReminderStatus, limit int) ([]*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders r WHERE r.status = $1 ORDER BY r.trigger_at DESC LIMIT $2`
+	rows, err := conn(ctx, r.db).Query(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectReminders(rows)
+}
+
+// CountByStatus returns the number of reminders per status
+func (r *ReminderRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT status, COUNT(*) FROM reminders GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// GetPending returns due reminders of active users whose retry backoff has passed
 func (r *ReminderRepository) GetPending(ctx context.Context, now time.Time) ([]*domain.Reminder, error) {
-	query := `SELECT id, trip_id, user_id, message, trigger_at, status FROM reminders WHERE status = $1 and trigger_at <= $2`
-	rows, err := r.db.Query(ctx, query, domain.StatusPending, now)
+	query := `SELECT ` + reminderColumns + ` FROM reminders r
+						JOIN users u ON u.id = r.user_id
+						WHERE r.status = $1 AND r.trigger_at <= $2
+							AND (r.next_attempt_at IS NULL OR r.next_attempt_at <= $2)
+							AND u.is_active`
+	rows, err := conn(ctx, r.db).Query(ctx, query, domain.StatusPending, now)
+	if err != nil {
+		return nil, err
+	}
+	return collectReminders(rows)
+}
+
+// ClaimPending atomically leases up to limit due reminders to the caller;
+// each returned reminder's LockedUntil is its lease. Rows locked by
+// concurrent claimers are skipped, so several pollers or bot replicas never
+// get the same reminder. Reminders stuck in processing after their lease
+// expired (crashed worker) are claimed again, and that counts as an attempt.
+func (r *ReminderRepository) ClaimPending(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*domain.Reminder, error) {
+	query := `UPDATE reminders r SET status = $1, locked_until = $2,
+							attempts = r.attempts + CASE WHEN r.status = $1 THEN 1 ELSE 0 END
+						WHERE r.id IN (
+							SELECT c.id FROM reminders c
+							JOIN users u ON u.id = c.user_id
+							WHERE u.is_active AND (
+								(c.status = $3 AND c.trigger_at <= $4 AND (c.next_attempt_at IS NULL OR c.next_attempt_at <= $4))
+								OR (c.status = $1 AND c.locked_until <= $4)
+							)
+							ORDER BY c.trigger_at
+							LIMIT $5
+							FOR UPDATE OF c SKIP LOCKED
+						)
+						RETURNING ` + reminderColumns
+	rows, err := conn(ctx, r.db).Query(ctx, query, domain.StatusProcessing, now.Add(lease), domain.StatusPending, now, limit)
 	if err != nil {
 		return nil, err
 	}
+	return collectReminders(rows)
+}
+
+func scanReminder(row pgx.Row) (*domain.Reminder, error) {
+	reminder := &domain.Reminder{}
+	err := row.Scan(&reminder.ID, &reminder.TripID, &reminder.UserID, &reminder.Message, &reminder.TriggerAt, &reminder.Status,
+		&reminder.Attempts, &reminder.LastError, &reminder.NextAttemptAt, &reminder.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func collectReminders(rows pgx.Rows) ([]*domain.Reminder, error) {
 	defer rows.Close()
-	pendings := make([]*domain.Reminder, 0, 10)
+	reminders := make([]*domain.Reminder, 0, 10)
 	for rows.Next() {
-		reminder := &domain.Reminder{}
-		err := rows.Scan(&reminder.ID, &reminder.TripID, &reminder.UserID, &reminder.Message, &reminder.TriggerAt, &reminder.Status)
+		reminder, err := scanReminder(rows)
 		if err != nil {
 			return nil, err
 		}
-		pendings = append(pendings, reminder)
+		reminders = append(reminders, reminder)
 	}
 
 	if rows.Err() != nil {
 		return nil, rows.Err()
 	}
 
-	return pendings, nil
+	return reminders, nil
+}
+
+// nullableID maps zero ids to NULL for optional foreign keys
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
 }
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SettingsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSettingsRepository(db *pgxpool.Pool) *SettingsRepository {
+	return &SettingsRepository{
+		db: db,
+	}
+}
+
+func (s *SettingsRepository) GetByUserID(ctx context.Context, userID int64) (*domain.UserSettings, error) {
+	query := `SELECT user_id, reminder_lead_minutes, language, timezone, home_station, work_station,
+						quiet_hours_start, quiet_hours_end, show_details
+						FROM user_settings WHERE user_id = $1`
+
+	st := &domain.UserSettings{}
+	err := conn(ctx, s.db).QueryRow(ctx, query, userID).Scan(&st.UserID, &st.ReminderLeadMinutes, &st.Language, &st.Timezone,
+		&st.HomeStation, &st.WorkStation, &st.QuietHoursStart, &st.QuietHoursEnd, &st.ShowDetails)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSettingsNotFound
+		}
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *SettingsRepository) Upsert(ctx context.Context, st *domain.UserSettings) error {
+	query := `INSERT INTO user_settings (user_id, reminder_lead_minutes, language, timezone, home_station, work_station,
+						quiet_hours_start, quiet_hours_end, show_details)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+						ON CONFLICT (user_id) DO UPDATE SET
+							reminder_lead_minutes = EXCLUDED.reminder_lead_minutes,
+							language = EXCLUDED.language,
+							timezone = EXCLUDED.timezone,
+							home_station = EXCLUDED.home_station,
+							work_station = EXCLUDED.work_station,
+							quiet_hours_start = EXCLUDED.quiet_hours_start,
+							quiet_hours_end = EXCLUDED.quiet_hours_end,
+							show_details = EXCLUDED.show_details`
+	_, err := conn(ctx, s.db).Exec(ctx, query, st.UserID, st.ReminderLeadMinutes, st.Language, st.Timezone, st.HomeStation,
+		st.WorkStation, st.QuietHoursStart, st.QuietHoursEnd, st.ShowDetails)
+	return err
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// likeEscaper escapes LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type StationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewStationRepository(db *pgxpool.Pool) *StationRepository {
+	return &StationRepository{
+		db: db,
+	}
+}
+
+// Upsert inserts stations or updates names of known codes, returns the number of rows written
+func (s *StationRepository) Upsert(ctx context.Context, stations []domain.Station) (int, error) {
+	query := `INSERT INTO stations (code, name) VALUES ($1, $2)
+						ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name`
+
+	batch := &pgx.Batch{}
+	for _, st := range stations {
+		batch.Queue(query, st.Code, st.Name)
+	}
+
+	results := conn(ctx, s.db).SendBatch(ctx, batch)
+	defer results.Close()
+
+	written := 0
+	for range stations {
+		if _, err := results.Exec(); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+func (s *StationRepository) GetByCode(ctx context.Context, code string) (*domain.Station, error) {
+	query := `SELECT code, name FROM stations WHERE code = $1`
+	st := &domain.Station{}
+	err := conn(ctx, s.db).QueryRow(ctx, query, code).Scan(&st.Code, &st.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrStationNotFound
+		}
+		return nil, err
+	}
+	return st, nil
+}
+
+// Search finds stations by case-insensitive name substring, prefix matches first
+func (s *StationRepository) Search(ctx context.Context, query string, limit int) ([]domain.Station, error) {
+	sqlQuery := `SELECT code, name FROM stations
+						WHERE LOWER(name) LIKE '%' || LOWER($1) || '%'
+						ORDER BY LOWER(name) LIKE LOWER($1) || '%' DESC, name
+						LIMIT $2`
+	rows, err := conn(ctx, s.db).Query(ctx, sqlQuery, likeEscaper.Replace(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stations := make([]domain.Station, 0, limit)
+	for rows.Next() {
+		var st domain.Station
+		if err := rows.Scan(&st.Code, &st.Name); err != nil {
+			return nil, err
+		}
+		stations = append(stations, st)
+	}
+	return stations, rows.Err()
+}
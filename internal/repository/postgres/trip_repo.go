@@ -3,12 +3,21 @@ package postgres
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const tripColumns = `id, user_id, from_station, to_station, from_name, to_name, train_number, train_title,
+						book_id, departure_time, arrival_time, COALESCE(duration_seconds, 0),
+						COALESCE(request_id, ''), created_at, COALESCE(chat_id, 0)`
+
+// userTrips matches the trips user $1 booked or joined as a companion
+const userTrips = `(user_id = $1 OR id IN (SELECT trip_id FROM trip_participants WHERE user_id = $1))`
+
 type TripRepository struct {
 	db *pgxpool.Pool 
 }
@@ -20,10 +29,12 @@ func NewTripRepository(db *pgxpool.Pool) *TripRepository {
 }
 
 func (t *TripRepository) Create(ctx context.Context, tr *domain.Trip) error {
-	query := `INSERT INTO trips (user_id, from_station, to_station, book_id, departure_time) 
-						VALUES ($1, $2, $3, $4, $5)
-						RETURNING id`	
-	err := t.db.QueryRow(ctx, query, tr.UserID, tr.From, tr.To, tr.BookID, tr.DepartureTime).Scan(&tr.ID)
+	query := `INSERT INTO trips (user_id, from_station, to_station, from_name, to_name, train_number, train_title,
+							book_id, departure_time, arrival_time, duration_seconds, request_id, chat_id) 
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+						RETURNING id, created_at`	
+	err := conn(ctx, t.db).QueryRow(ctx, query, tr.UserID, tr.From, tr.To, tr.FromName, tr.ToName, tr.TrainNumber, tr.TrainTitle,
+		tr.BookID, tr.DepartureTime, nullableTime(tr.ArrivalTime), nullableSeconds(tr.Duration), nullableString(tr.RequestID), nullableID(tr.ChatID)).Scan(&tr.ID, &tr.CreatedAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
     if errors.As(err, &pgErr) {
@@ -36,23 +47,348 @@ func (t *TripRepository) Create(ctx context.Context, tr *domain.Trip) error {
 	return nil
 } 
 
+func (t *TripRepository) GetByID(ctx context.Context, id int64) (*domain.Trip, error) {
+	return t.getOne(ctx, `SELECT `+tripColumns+` FROM trips WHERE id = $1`, id)
+}
+
+func (t *TripRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Trip, error) {
+	return t.getOne(ctx, `SELECT `+tripColumns+` FROM trips WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (t *TripRepository) GetByRequestID(ctx context.Context, requestID string) (*domain.Trip, error) {
+	return t.getOne(ctx, `SELECT `+tripColumns+` FROM trips WHERE request_id = $1`, requestID)
+}
+
+func (t *TripRepository) GetByTrain(ctx context.Context, userID int64, trainNumber string, departure time.Time) (*domain.Trip, error) {
+	query := `SELECT ` + tripColumns + ` FROM trips WHERE user_id = $1 AND train_number = $2 AND departure_time = $3`
+	return t.getOne(ctx, query, userID, trainNumber, departure)
+}
+
+func (t *TripRepository) getOne(ctx context.Context, query string, args ...any) (*domain.Trip, error) {
+	rows, err := conn(ctx, t.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	trips, err := collectTrips(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(trips) == 0 {
+		return nil, domain.ErrTripNotFound
+	}
+	return trips[0], nil
+}
+
 func (t *TripRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Trip, error) {
-	query := `SELECT * FROM trips WHERE user_id = $1`
-	rows, err := t.db.Query(ctx, query, userID)
+	query := `SELECT ` + tripColumns + ` FROM trips WHERE ` + userTrips
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectTrips(rows)
+}
+
+func (t *TripRepository) CountCreatedSince(ctx context.Context, since time.Time) (int, error) {
+	var n int
+	err := conn(ctx, t.db).QueryRow(ctx, `SELECT COUNT(*) FROM trips WHERE created_at >= $1`, since).Scan(&n)
+	return n, err
+}
+
+func (t *TripRepository) GetUpcoming(ctx context.Context, userID int64, now time.Time, limit, offset int) ([]*domain.Trip, error) {
+	query := `SELECT ` + tripColumns + ` FROM trips
+						WHERE ` + userTrips + ` AND departure_time > $2
+						ORDER BY departure_time, id
+						LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID, now, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return collectTrips(rows)
+}
+
+func (t *TripRepository) GetPast(ctx context.Context, userID int64, now time.Time, limit, offset int) ([]*domain.Trip, error) {
+	query := `SELECT ` + tripColumns + ` FROM trips
+						WHERE ` + userTrips + ` AND departure_time <= $2
+						ORDER BY departure_time DESC, id DESC
+						LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID, now, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return collectTrips(rows)
+}
+
+func (t *TripRepository) CountByUser(ctx context.Context, userID int64, now time.Time) (upcoming, past int, err error) {
+	query := `SELECT COUNT(*) FILTER (WHERE departure_time > $2), COUNT(*) FILTER (WHERE departure_time <= $2)
+						FROM trips WHERE ` + userTrips
+	err = conn(ctx, t.db).QueryRow(ctx, query, userID, now).Scan(&upcoming, &past)
+	return upcoming, past, err
+}
+
+// CountByMonth groups trips departed in [since, now] by month in loc.
+// Months without trips are omitted.
+func (t *TripRepository) CountByMonth(ctx context.Context, userID int64, since, now time.Time, loc *time.Location) ([]domain.TripMonthStat, error) {
+	query := `SELECT TO_CHAR(DATE_TRUNC('month', departure_time AT TIME ZONE $4), 'YYYY-MM') AS month, COUNT(*)
+						FROM trips
+						WHERE ` + userTrips + ` AND departure_time >= $2 AND departure_time <= $3
+						GROUP BY month
+						ORDER BY month`
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID, since, now, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]domain.TripMonthStat, 0, 12)
+	for rows.Next() {
+		var month string
+		var stat domain.TripMonthStat
+		if err := rows.Scan(&month, &stat.Trips); err != nil {
+			return nil, err
+		}
+		if stat.Month, err = time.ParseInLocation("2006-01", month, loc); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// TopRoutes returns the most frequent routes among past trips
+func (t *TripRepository) TopRoutes(ctx context.Context, userID int64, now time.Time, limit int) ([]domain.RouteStat, error) {
+	query := `SELECT from_station, to_station, MAX(from_name), MAX(to_name), COUNT(*) AS trips
+						FROM trips
+						WHERE ` + userTrips + ` AND departure_time <= $2
+						GROUP BY from_station, to_station
+						ORDER BY trips DESC, MAX(departure_time) DESC
+						LIMIT $3`
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := make([]domain.RouteStat, 0, limit)
+	for rows.Next() {
+		var route domain.RouteStat
+		if err := rows.Scan(&route.From, &route.To, &route.FromName, &route.ToName, &route.Trips); err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, rows.Err()
+}
+
+func (t *TripRepository) Totals(ctx context.Context, userID int64, now time.Time) (domain.TripTotals, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(duration_seconds), 0),
+							(SELECT COALESCE(SUM(pages), 0) FROM trip_reading WHERE user_id = $1)
+						FROM trips
+						WHERE ` + userTrips + ` AND departure_time <= $2`
+	var totals domain.TripTotals
+	var seconds int64
+	err := conn(ctx, t.db).QueryRow(ctx, query, userID, now).Scan(&totals.Trips, &seconds, &totals.PagesRead)
+	totals.TrainTime = time.Duration(seconds) * time.Second
+	return totals, err
+}
+
+func (t *TripRepository) UpdateSchedule(ctx context.Context, tr *domain.Trip) error {
+	query := `UPDATE trips SET train_number = $2, train_title = $3, departure_time = $4, arrival_time = $5,
+							duration_seconds = $6
+						WHERE id = $1`
+	tag, err := conn(ctx, t.db).Exec(ctx, query, tr.ID, tr.TrainNumber, tr.TrainTitle, tr.DepartureTime,
+		nullableTime(tr.ArrivalTime), nullableSeconds(tr.Duration))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == domain.ErrUniqueViolation {
+			return domain.ErrTripAlreadyExists
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTripNotFound
+	}
+	return nil
+}
+
+func (t *TripRepository) RecordChange(ctx context.Context, c *domain.TripChange) error {
+	query := `INSERT INTO trip_changes (trip_id, user_id, old_train_number, old_train_title, old_departure_time, old_arrival_time,
+							new_train_number, new_train_title, new_departure_time, new_arrival_time)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+						RETURNING id, changed_at`
+	return conn(ctx, t.db).QueryRow(ctx, query, c.TripID, c.UserID,
+		c.OldTrainNumber, c.OldTrainTitle, c.OldDeparture, nullableTime(c.OldArrival),
+		c.NewTrainNumber, c.NewTrainTitle, c.NewDeparture, nullableTime(c.NewArrival)).Scan(&c.ID, &c.ChangedAt)
+}
+
+func (t *TripRepository) GetChanges(ctx context.Context, tripID int64) ([]*domain.TripChange, error) {
+	query := `SELECT id, trip_id, user_id, old_train_number, old_train_title, old_departure_time, old_arrival_time,
+							new_train_number, new_train_title, new_departure_time, new_arrival_time, changed_at
+						FROM trip_changes WHERE trip_id = $1 ORDER BY changed_at, id`
+	rows, err := conn(ctx, t.db).Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*domain.TripChange, 0, 2)
+	for rows.Next() {
+		c := &domain.TripChange{}
+		var oldArrival, newArrival *time.Time
+		err := rows.Scan(&c.ID, &c.TripID, &c.UserID, &c.OldTrainNumber, &c.OldTrainTitle, &c.OldDeparture, &oldArrival,
+			&c.NewTrainNumber, &c.NewTrainTitle, &c.NewDeparture, &newArrival, &c.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		if oldArrival != nil {
+			c.OldArrival = *oldArrival
+		}
+		if newArrival != nil {
+			c.NewArrival = *newArrival
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// AddPagesRead credits pages to the user's trip under way at now: departed
+// and not arrived more than grace ago. Without a known arrival the trip is
+// taken to last fallback.
+func (t *TripRepository) AddPagesRead(ctx context.Context, userID int64, bookID int64, pages int, now time.Time, grace, fallback time.Duration) (bool, error) {
+	query := `INSERT INTO trip_reading (trip_id, user_id, book_id, pages)
+						SELECT id, $1, $2, $3 FROM trips
+						WHERE ` + userTrips + ` AND departure_time <= $4
+							AND $4 <= COALESCE(arrival_time, departure_time + make_interval(secs => COALESCE(duration_seconds, $6::float8)))
+								+ make_interval(secs => $5::float8)
+						ORDER BY departure_time DESC
+						LIMIT 1`
+	tag, err := conn(ctx, t.db).Exec(ctx, query, userID, bookID, pages, now, grace.Seconds(), fallback.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (t *TripRepository) PagesReadByTrip(ctx context.Context, userID int64) (map[int64]int, error) {
+	query := `SELECT trip_id, SUM(pages) FROM trip_reading WHERE user_id = $1 GROUP BY trip_id`
+	rows, err := conn(ctx, t.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := make(map[int64]int)
+	for rows.Next() {
+		var tripID int64
+		var n int
+		if err := rows.Scan(&tripID, &n); err != nil {
+			return nil, err
+		}
+		pages[tripID] = n
+	}
+	return pages, rows.Err()
+}
+
+func (t *TripRepository) AddParticipant(ctx context.Context, tripID, userID int64) error {
+	query := `INSERT INTO trip_participants (trip_id, user_id) VALUES ($1, $2)`
+	_, err := conn(ctx, t.db).Exec(ctx, query, tripID, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == domain.ErrUniqueViolation {
+			return domain.ErrTripAlreadyJoined
+		}
+		return err
+	}
+	return nil
+}
+
+// HandOver moves the user's group trips to the companion who joined first,
+// unless that companion already has a trip on the same train. The new owner
+// stops being a participant.
+func (t *TripRepository) HandOver(ctx context.Context, userID int64) error {
+	query := `WITH heirs AS (
+							SELECT DISTINCT ON (p.trip_id) p.trip_id, p.user_id
+							FROM trip_participants p JOIN trips t ON t.id = p.trip_id
+							WHERE t.user_id = $1 AND NOT EXISTS (
+								SELECT 1 FROM trips o
+								WHERE o.user_id = p.user_id AND o.train_number = t.train_number
+									AND o.departure_time = t.departure_time AND t.train_number <> ''
+							)
+							ORDER BY p.trip_id, p.joined_at, p.user_id
+						), moved AS (
+							UPDATE trips t SET user_id = h.user_id
+							FROM heirs h
+							WHERE t.id = h.trip_id
+							RETURNING t.id, t.user_id
+						)
+						DELETE FROM trip_participants p USING moved m
+						WHERE p.trip_id = m.id AND p.user_id = m.user_id`
+	_, err := conn(ctx, t.db).Exec(ctx, query, userID)
+	return err
+}
+
+func (t *TripRepository) GetParticipants(ctx context.Context, tripID int64) ([]*domain.User, error) {
+	query := `SELECT u.id, u.telegram_id, u.name, u.username, u.is_active, COALESCE(u.chat_id, 0)
+						FROM trip_participants p JOIN users u ON u.id = p.user_id
+						WHERE p.trip_id = $1
+						ORDER BY p.joined_at, u.id`
+	rows, err := conn(ctx, t.db).Query(ctx, query, tripID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()	
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user := &domain.User{}
+		if err := rows.Scan(&user.ID, &user.TelegramID, &user.Name, &user.Username, &user.IsActive, &user.ChatID); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func collectTrips(rows pgx.Rows) ([]*domain.Trip, error) {
+	defer rows.Close()
 
 	trips := make([]*domain.Trip, 0, 10)
 	for rows.Next() {
 		tr := &domain.Trip{}
-		err := rows.Scan(&tr.ID, &tr.UserID, &tr.From, &tr.To, &tr.BookID, &tr.DepartureTime)
+		var seconds int64
+		var arrival *time.Time
+		err := rows.Scan(&tr.ID, &tr.UserID, &tr.From, &tr.To, &tr.FromName, &tr.ToName, &tr.TrainNumber, &tr.TrainTitle,
+			&tr.BookID, &tr.DepartureTime, &arrival, &seconds, &tr.RequestID, &tr.CreatedAt, &tr.ChatID)
 		if err != nil {
 			return nil, err
 		}
+		tr.Duration = time.Duration(seconds) * time.Second
+		if arrival != nil {
+			tr.ArrivalTime = *arrival
+		}
 		trips = append(trips, tr)
-	}	
+	}
+	return trips, rows.Err()
+}
 
-	return trips, nil 
-}
\ No newline at end of file
+// nullableSeconds stores an unknown duration as NULL
+func nullableSeconds(d time.Duration) *int64 {
+	if d <= 0 {
+		return nil
+	}
+	seconds := int64(d / time.Second)
+	return &seconds
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -3,13 +3,15 @@ package postgres
 import (
 	"context"
 	"errors"
-	"log"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const userColumns = `id, telegram_id, name, username, is_active, COALESCE(chat_id, 0)`
+
 type UserRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,10 +23,10 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 }
 
 func (u *UserRepository) Create(ctx context.Context, user *domain.User) error {
-	query := `INSERT INTO users (telegram_id, name, username) 
-						VALUES ($1, $2, $3)
+	query := `INSERT INTO users (telegram_id, name, username, chat_id) 
+						VALUES ($1, $2, $3, $4)
 						RETURNING id`
-	err := u.db.QueryRow(ctx, query, user.TelegramID, user.Name, user.Username).Scan(&user.ID)
+	err := conn(ctx, u.db).QueryRow(ctx, query, user.TelegramID, user.Name, user.Username, nullableID(user.ChatID)).Scan(&user.ID)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -40,24 +42,114 @@ func (u *UserRepository) Create(ctx context.Context, user *domain.User) error {
 }
 
 func (u *UserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
-	query := `SELECT id, name, username FROM users WHERE telegram_id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE telegram_id = $1`
 
 	user := &domain.User{}
-	err := u.db.QueryRow(ctx, query, telegramID).Scan(&user.ID, &user.Name, &user.Username)
+	err := conn(ctx, u.db).QueryRow(ctx, query, telegramID).Scan(&user.ID, &user.TelegramID, &user.Name, &user.Username, &user.IsActive, &user.ChatID)
 	if err != nil {
-		log.Println(err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return user, nil
 }
 
 func (u *UserRepository) GetByID(ctx context.Context, userID int64) (*domain.User, error) {
-	query := `SELECT telegram_id, name, username FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
 	user := &domain.User{}
-	err := u.db.QueryRow(ctx, query, userID).Scan(&user.TelegramID, &user.Name, &user.Username)
+	err := conn(ctx, u.db).QueryRow(ctx, query, userID).Scan(&user.ID, &user.TelegramID, &user.Name, &user.Username, &user.IsActive, &user.ChatID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *UserRepository) SetActive(ctx context.Context, userID int64, active bool) error {
+	query := `UPDATE users SET is_active = $2 WHERE id = $1`
+	_, err := conn(ctx, u.db).Exec(ctx, query, userID, active)
+	return err
+}
+
+// SetChatID stores the user's private chat once they write to the bot directly
+func (u *UserRepository) SetChatID(ctx context.Context, userID, chatID int64) error {
+	query := `UPDATE users SET chat_id = $2 WHERE id = $1`
+	_, err := conn(ctx, u.db).Exec(ctx, query, userID, chatID)
+	return err
+}
+
+func (u *UserRepository) List(ctx context.Context) ([]*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY id`
+	rows, err := conn(ctx, u.db).Query(ctx, query)
 	if err != nil {
-		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0, 10)
+	for rows.Next() {
+		user := &domain.User{}
+		if err := rows.Scan(&user.ID, &user.TelegramID, &user.Name, &user.Username, &user.IsActive, &user.ChatID); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (u *UserRepository) Count(ctx context.Context) (total, active int, err error) {
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE is_active) FROM users`
+	err = conn(ctx, u.db).QueryRow(ctx, query).Scan(&total, &active)
+	return total, active, err
+}
+
+func (u *UserRepository) Delete(ctx context.Context, userID int64) error {
+	query := `DELETE FROM users WHERE id = $1`
+	rows, err := conn(ctx, u.db).Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (u *UserRepository) SetCalendarToken(ctx context.Context, userID int64, token string) error {
+	query := `UPDATE users SET calendar_token = $2 WHERE id = $1`
+	rows, err := conn(ctx, u.db).Exec(ctx, query, userID, nullableString(token))
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (u *UserRepository) GetCalendarToken(ctx context.Context, userID int64) (string, error) {
+	query := `SELECT COALESCE(calendar_token, '') FROM users WHERE id = $1`
+	var token string
+	err := conn(ctx, u.db).QueryRow(ctx, query, userID).Scan(&token)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrUserNotFound
+	}
+	return token, err
+}
+
+func (u *UserRepository) GetByCalendarToken(ctx context.Context, token string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE calendar_token = $1`
+
+	user := &domain.User{}
+	err := conn(ctx, u.db).QueryRow(ctx, query, token).Scan(&user.ID, &user.TelegramID, &user.Name, &user.Username, &user.IsActive, &user.ChatID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return user, nil
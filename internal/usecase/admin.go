@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+var (
+	ErrReminderNotFailed = domain.NewError("reminder.not_failed", "reminder is not in failed status")
+)
+
+// statsWindow is the period the schedule API error rate is reported for
+const statsWindow = time.Hour
+
+// ScheduleStats reports recent requests to the schedule API
+type ScheduleStats interface {
+	RequestStats(window time.Duration) (total, failures int)
+}
+
+type AdminUsecase struct {
+	userRepo      domain.UserRepository
+	tripRepo      domain.TripRepository
+	reminderRepo  domain.ReminderRepository
+	scheduleStats ScheduleStats
+	adminIDs      map[int64]bool
+}
+
+func NewAdminUsecase(userRepo domain.UserRepository, tripRepo domain.TripRepository, reminderRepo domain.ReminderRepository, scheduleStats ScheduleStats, adminIDs []int64) *AdminUsecase {
+	ids := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		ids[id] = true
+	}
+	return &AdminUsecase{
+		userRepo:      userRepo,
+		tripRepo:      tripRepo,
+		reminderRepo:  reminderRepo,
+		scheduleStats: scheduleStats,
+		adminIDs:      ids,
+	}
+}
+
+func (a *AdminUsecase) IsAdmin(telegramID int64) bool {
+	return a.adminIDs[telegramID]
+}
+
+type Stats struct {
+	Users       int
+	ActiveUsers int
+	TripsToday  int
+	// Reminders per status
+	Reminders map[string]int
+
+	ScheduleWindow   time.Duration
+	ScheduleRequests int
+	ScheduleFailures int
+}
+
+// ScheduleErrorRate is the share of failed schedule API requests, 0..1
+func (s *Stats) ScheduleErrorRate() float64 {
+	if s.ScheduleRequests == 0 {
+		return 0
+	}
+	return float64(s.ScheduleFailures) / float64(s.ScheduleRequests)
+}
+
+// Stats collects counters for /stats; "today" starts at midnight in loc
+func (a *AdminUsecase) Stats(ctx context.Context, now time.Time, loc *time.Location) (*Stats, error) {
+	st := &Stats{ScheduleWindow: statsWindow}
+
+	var err error
+	if st.Users, st.ActiveUsers, err = a.userRepo.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	local := now.In(loc)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if st.TripsToday, err = a.tripRepo.CountCreatedSince(ctx, dayStart); err != nil {
+		return nil, err
+	}
+
+	if st.Reminders, err = a.reminderRepo.CountByStatus(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.scheduleStats != nil {
+		st.ScheduleRequests, st.ScheduleFailures = a.scheduleStats.RequestStats(statsWindow)
+	}
+	return st, nil
+}
+
+type UserOverview struct {
+	User      *domain.User
+	Trips     []*domain.Trip
+	Reminders []*domain.Reminder
+}
+
+// UserOverview looks the user up by Telegram ID first, then by internal ID
+func (a *AdminUsecase) UserOverview(ctx context.Context, id int64) (*UserOverview, error) {
+	user, err := a.userRepo.GetByTelegramID(ctx, id)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		user, err = a.userRepo.GetByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trips, err := a.tripRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := a.reminderRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserOverview{User: user, Trips: trips, Reminders: reminders}, nil
+}
+
+// BroadcastRecipients returns users that haven't blocked the bot and have a
+// private chat with it; those who only used it in groups can't be written to
+func (a *AdminUsecase) BroadcastRecipients(ctx context.Context) ([]*domain.User, error) {
+	users, err := a.userRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := users[:0]
+	for _, u := range users {
+		if u.IsActive && u.ChatID != 0 {
+			active = append(active, u)
+		}
+	}
+	return active, nil
+}
+
+func (a *AdminUsecase) FailedReminders(ctx context.Context, limit int) ([]*domain.Reminder, error) {
+	return a.reminderRepo.GetByStatus(ctx, domain.StatusFailed, limit)
+}
+
+// Requeue makes a failed reminder pending again with a fresh attempt budget;
+// it is delivered right away
+func (a *AdminUsecase) Requeue(ctx context.Context, reminderID int64, now time.Time) error {
+	rem, err := a.reminderRepo.GetByID(ctx, reminderID)
+	if err != nil {
+		return err
+	}
+	if rem.Status != string(domain.StatusFailed) {
+		return ErrReminderNotFailed
+	}
+	return a.reminderRepo.Reschedule(ctx, rem.ID, now)
+}
+
+// RequeueFailed requeues up to limit failed reminders and returns how many
+func (a *AdminUsecase) RequeueFailed(ctx context.Context, limit int, now time.Time) (int, error) {
+	failed, err := a.FailedReminders(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, rem := range failed {
+		if err := a.reminderRepo.Reschedule(ctx, rem.ID, now); err != nil {
+			return i, err
+		}
+	}
+	return len(failed), nil
+}
@@ -2,24 +2,43 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
+	"unicode/utf8"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/userdata"
 )
 
 
+// Progress saved up to readingGrace after arrival still counts as read on
+// the trip; a trip with no known arrival or duration is taken to last
+// readingFallback
+const (
+	readingGrace    = time.Hour
+	readingFallback = 12 * time.Hour
+)
+
 type BookUsecase struct {
 	bookRepo     domain.BookRepository
 	userRepo     domain.UserRepository
 	reminderRepo domain.ReminderRepository
+	tripRepo     domain.TripRepository
+	settingsRepo domain.SettingsRepository
+	tx           domain.Transactor
 }
 
-func NewBookUsecase(bookRepo domain.BookRepository, userRepo domain.UserRepository, reminderRepo domain.ReminderRepository) *BookUsecase {
+func NewBookUsecase(bookRepo domain.BookRepository, userRepo domain.UserRepository, reminderRepo domain.ReminderRepository, tripRepo domain.TripRepository, settingsRepo domain.SettingsRepository, tx domain.Transactor) *BookUsecase {
 	return &BookUsecase{
+		tx:           tx,
 		bookRepo:     bookRepo,
 		userRepo:     userRepo,
 		reminderRepo: reminderRepo,
+		tripRepo:     tripRepo,
+		settingsRepo: settingsRepo,
 	}
 }
 
@@ -28,14 +47,92 @@ func (b *BookUsecase) GetByUserID(ctx context.Context, userID int64) ([]*domain.
 }
 
 func (b *BookUsecase) Create(ctx context.Context, book *domain.Book) error {
+	if err := validateBook(book); err != nil {
+		return err
+	}
+
+	return b.bookRepo.Create(ctx, book)
+}
+
+// BookImportError is a row of an import file that was not added
+type BookImportError struct {
+	Line int
+	Err  error
+}
+
+type BookImportResult struct {
+	Imported   int
+	Duplicates int // already in the user's list
+	Failed     []BookImportError
+	// AbortedLine is the row whose insert failed; nothing was imported then
+	AbortedLine int
+}
+
+// maxBookNameLength matches books.book_name VARCHAR(255)
+const maxBookNameLength = 255
+
+// Import adds the books of a CSV file to the user's list, validating each row
+// like Create. Invalid rows and duplicates are skipped and reported; the
+// valid rows are added in one transaction, so a failed insert adds none.
+func (b *BookUsecase) Import(ctx context.Context, userID int64, r io.Reader) (*BookImportResult, error) {
+	rows, err := userdata.ReadBooks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BookImportResult{}
+	var valid []userdata.BookRow
+	for _, row := range rows {
+		row.Book.UserID = userID
+		if row.Err == nil {
+			row.Err = validateBook(&row.Book)
+		}
+		if row.Err != nil {
+			res.Failed = append(res.Failed, BookImportError{Line: row.Line, Err: row.Err})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	err = b.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, row := range valid {
+			err := b.bookRepo.Create(ctx, &row.Book)
+			switch {
+			case err == nil:
+				res.Imported++
+			case errors.Is(err, domain.ErrBookAlreadyExists):
+				res.Duplicates++
+			default:
+				res.AbortedLine = row.Line
+				return fmt.Errorf("import line %d: %w", row.Line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		res.Imported, res.Duplicates = 0, 0
+		return res, err
+	}
+	return res, nil
+}
+
+func validateBook(book *domain.Book) error {
 	if book.TotalPages <= 0 {
 		return domain.ErrPagesMustNonZero
 	}
 	if book.BookName == "" {
 		return domain.ErrBookNameEmpty
 	}
-
-	return b.bookRepo.Create(ctx, book)
+	if utf8.RuneCountInString(book.BookName) > maxBookNameLength {
+		return domain.ErrBookNameTooLong
+	}
+	if book.CurrentPages < 0 {
+		return domain.ErrPagesMustNonZero
+	}
+	if book.CurrentPages > book.TotalPages {
+		return domain.ErrPagesOverall
+	}
+	return nil
 }
 
 func (b *BookUsecase) Delete(ctx context.Context, bookID int64) error {
@@ -57,17 +154,26 @@ func (b *BookUsecase) UpdateProgress(ctx context.Context, userID int64, bookID i
 	if currentPages < 0 {
 		return domain.ErrPagesMustNonZero
 	}
-	err = b.bookRepo.UpdateProgress(ctx, bookID, currentPages)
-	if err != nil {
-		return err
-	}
-	if currentPages == book.TotalPages {
-		_ = b.reminderRepo.Create(ctx, &domain.Reminder{
-			UserID:    userID,
-			Message:   fmt.Sprintf("Вы закончили книгу %s", book.BookName),
-			TriggerAt: time.Now(),
-		})
-	}
-
-	return nil
+	return b.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := b.bookRepo.UpdateProgress(ctx, bookID, currentPages); err != nil {
+			return err
+		}
+		if read := currentPages - book.CurrentPages; read > 0 {
+			if _, err := b.tripRepo.AddPagesRead(ctx, userID, bookID, read, time.Now(), readingGrace, readingFallback); err != nil {
+				return err
+			}
+		}
+		if currentPages == book.TotalPages {
+			settings, err := settingsOrDefault(ctx, b.settingsRepo, userID)
+			if err != nil {
+				return err
+			}
+			return b.reminderRepo.Create(ctx, &domain.Reminder{
+				UserID:    userID,
+				Message:   i18n.For(settings.Language).T("reminder.book_finished", book.BookName),
+				TriggerAt: time.Now(),
+			})
+		}
+		return nil
+	})
 }
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/calendar"
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+)
+
+var (
+	ErrCalendarLinkDisabled = domain.NewError("calendar.links_disabled", "calendar subscription links are not configured")
+	ErrCalendarNotFound     = domain.NewError("calendar.not_found", "calendar not found")
+)
+
+const (
+	// calendarTripLimit caps the trips in one export
+	calendarTripLimit = 500
+	// CalendarPathPrefix is where the HTTP server serves subscriptions
+	CalendarPathPrefix = "/calendar/"
+	calendarTokenBytes = 24
+)
+
+type CalendarUsecase struct {
+	tripRepo     domain.TripRepository
+	userRepo     domain.UserRepository
+	settingsRepo domain.SettingsRepository
+	baseURL      string
+}
+
+// NewCalendarUsecase creates the .ics export; subscription links are only
+// issued when baseURL, the bot's public HTTP address, is set
+func NewCalendarUsecase(tripRepo domain.TripRepository, userRepo domain.UserRepository, settingsRepo domain.SettingsRepository, baseURL string) *CalendarUsecase {
+	return &CalendarUsecase{
+		tripRepo:     tripRepo,
+		userRepo:     userRepo,
+		settingsRepo: settingsRepo,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// LinksEnabled reports whether subscription URLs can be issued
+func (c *CalendarUsecase) LinksEnabled() bool {
+	return c.baseURL != ""
+}
+
+// Export renders the user's upcoming trips as an .ics file and returns how
+// many trips it contains
+func (c *CalendarUsecase) Export(ctx context.Context, userID int64, now time.Time) ([]byte, int, error) {
+	trips, err := c.tripRepo.GetUpcoming(ctx, userID, now, calendarTripLimit, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	settings, err := settingsOrDefault(ctx, c.settingsRepo, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	l := i18n.For(settings.Language)
+	events := make([]calendar.Event, 0, len(trips))
+	for _, tr := range trips {
+		events = append(events, tripEvent(l, tr, settings))
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, l.T("calendar.name"), events, now); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), len(trips), nil
+}
+
+// ExportByToken renders the feed of the subscription token's owner
+func (c *CalendarUsecase) ExportByToken(ctx context.Context, token string, now time.Time) ([]byte, error) {
+	if token == "" {
+		return nil, ErrCalendarNotFound
+	}
+	user, err := c.userRepo.GetByCalendarToken(ctx, token)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, ErrCalendarNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := c.Export(ctx, user.ID, now)
+	return data, err
+}
+
+// SubscriptionURL returns the user's secret calendar URL, issuing a token on
+// first use
+func (c *CalendarUsecase) SubscriptionURL(ctx context.Context, userID int64) (string, error) {
+	if !c.LinksEnabled() {
+		return "", ErrCalendarLinkDisabled
+	}
+	token, err := c.userRepo.GetCalendarToken(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		if token, err = newCalendarToken(); err != nil {
+			return "", err
+		}
+		if err := c.userRepo.SetCalendarToken(ctx, userID, token); err != nil {
+			return "", err
+		}
+	}
+	return c.baseURL + CalendarPathPrefix + token + ".ics", nil
+}
+
+// RevokeSubscription invalidates the user's calendar URL
+func (c *CalendarUsecase) RevokeSubscription(ctx context.Context, userID int64) error {
+	return c.userRepo.SetCalendarToken(ctx, userID, "")
+}
+
+func newCalendarToken() (string, error) {
+	b := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate calendar token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func tripEvent(l *i18n.Localizer, tr *domain.Trip, settings *domain.UserSettings) calendar.Event {
+	loc := settings.Location()
+	route := tr.FromDisplay() + " → " + tr.ToDisplay()
+
+	summary := "🚆 " + route
+	var desc []string
+	if tr.TrainNumber != "" {
+		summary = fmt.Sprintf("🚆 %s %s", tr.TrainNumber, route)
+		desc = append(desc, l.T("calendar.train", strings.TrimSpace(tr.TrainNumber+" "+tr.TrainTitle)))
+	}
+	desc = append(desc, l.T("calendar.route", route), l.T("calendar.departure", tr.DepartureTime.In(loc).Format("02.01.2006 15:04")))
+
+	end := tr.ArrivalTime
+	if end.IsZero() && tr.Duration > 0 {
+		end = tr.DepartureTime.Add(tr.Duration)
+	}
+	if !end.IsZero() {
+		desc = append(desc, l.T("calendar.arrival", end.In(loc).Format("02.01.2006 15:04")))
+	}
+
+	return calendar.Event{
+		UID:         fmt.Sprintf("trip-%d@travelscheduler", tr.ID),
+		Summary:     summary,
+		Description: strings.Join(desc, "\n"),
+		Location:    tr.FromDisplay(),
+		Start:       tr.DepartureTime,
+		End:         end,
+		Alarm:       settings.ReminderLead(),
+	}
+}
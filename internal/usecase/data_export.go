@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/userdata"
+)
+
+var ErrExportFormat = domain.NewError("export.unknown_format", "unknown export format")
+
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+)
+
+// ExportFile is one document of a data export
+type ExportFile struct {
+	Name string
+	Data []byte
+	Rows int // records in the file
+}
+
+type DataExportUsecase struct {
+	tripRepo     domain.TripRepository
+	reminderRepo domain.ReminderRepository
+	bookRepo     domain.BookRepository
+	settingsRepo domain.SettingsRepository
+}
+
+func NewDataExportUsecase(tripRepo domain.TripRepository, reminderRepo domain.ReminderRepository, bookRepo domain.BookRepository, settingsRepo domain.SettingsRepository) *DataExportUsecase {
+	return &DataExportUsecase{
+		tripRepo:     tripRepo,
+		reminderRepo: reminderRepo,
+		bookRepo:     bookRepo,
+		settingsRepo: settingsRepo,
+	}
+}
+
+// Export returns the user's trips, including the ones they joined, reminders
+// and books: one JSON document, or a CSV file per table with times in the
+// user's timezone
+func (d *DataExportUsecase) Export(ctx context.Context, userID int64, format ExportFormat, now time.Time) ([]ExportFile, error) {
+	if format != ExportCSV && format != ExportJSON {
+		return nil, ErrExportFormat
+	}
+
+	trips, err := d.tripRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pagesRead, err := d.tripRepo.PagesReadByTrip(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := d.reminderRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	books, err := d.bookRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := settingsOrDefault(ctx, d.settingsRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	archive := userdata.NewArchive(userID, trips, pagesRead, reminders, books, settings.Location(), now)
+
+	if format == ExportJSON {
+		f, err := exportFile("travelpet.json", len(trips)+len(reminders)+len(books), archive.WriteJSON)
+		if err != nil {
+			return nil, err
+		}
+		return []ExportFile{f}, nil
+	}
+
+	files := make([]ExportFile, 0, 3)
+	for _, t := range []struct {
+		name  string
+		rows  int
+		write func(io.Writer) error
+	}{
+		{"trips.csv", len(trips), archive.WriteTripsCSV},
+		{"reminders.csv", len(reminders), archive.WriteRemindersCSV},
+		{"books.csv", len(books), archive.WriteBooksCSV},
+	} {
+		f, err := exportFile(t.name, t.rows, t.write)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func exportFile(name string, rows int, write func(io.Writer) error) (ExportFile, error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return ExportFile{}, err
+	}
+	return ExportFile{Name: name, Data: buf.Bytes(), Rows: rows}, nil
+}
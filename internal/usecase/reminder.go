@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+const (
+	// MaxDeliveryAttempts is how many times a reminder is tried before it is marked failed
+	MaxDeliveryAttempts = 5
+
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 30 * time.Minute
+)
+
+type ReminderUsecase struct {
+	reminderRepo domain.ReminderRepository
+	settingsRepo domain.SettingsRepository
+}
+
+func NewReminderUsecase(reminderRepo domain.ReminderRepository, settingsRepo domain.SettingsRepository) *ReminderUsecase {
+	return &ReminderUsecase{
+		reminderRepo: reminderRepo,
+		settingsRepo: settingsRepo,
+	}
+}
+
+// PlanDelivery decides how a due reminder is delivered given the user's quiet hours.
+// Trip reminders are never postponed, they are sent silently instead.
+func (r *ReminderUsecase) PlanDelivery(ctx context.Context, reminder *domain.Reminder, now time.Time) (domain.Delivery, error) {
+	settings, err := settingsOrDefault(ctx, r.settingsRepo, reminder.UserID)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+
+	if !settings.InQuietHours(now) {
+		return domain.Delivery{}, nil
+	}
+	if reminder.IsTrip() {
+		return domain.Delivery{Silent: true}, nil
+	}
+	return domain.Delivery{DeferUntil: settings.NextQuietHoursEnd(now)}, nil
+}
+
+// Defer puts a claimed reminder back until the given time
+func (r *ReminderUsecase) Defer(ctx context.Context, reminder *domain.Reminder, until time.Time) error {
+	return r.reminderRepo.Postpone(ctx, reminder.ID, reminder.Lease(), until)
+}
+
+// RecordFailure stores a failed delivery attempt and schedules a retry with
+// exponential backoff. Returns true when attempts are exhausted and the
+// reminder was moved to the failed status.
+func (r *ReminderUsecase) RecordFailure(ctx context.Context, reminder *domain.Reminder, cause error, now time.Time) (bool, error) {
+	attempt := reminder.Attempts + 1
+	if attempt >= MaxDeliveryAttempts {
+		return true, r.reminderRepo.MarkAsFailed(ctx, reminder.ID, reminder.Lease(), cause.Error())
+	}
+	return false, r.reminderRepo.MarkAttemptFailed(ctx, reminder.ID, reminder.Lease(), cause.Error(), now.Add(retryDelay(attempt)))
+}
+
+// MarkFailed moves the reminder straight to the failed status without retries
+func (r *ReminderUsecase) MarkFailed(ctx context.Context, reminder *domain.Reminder, cause error) error {
+	return r.reminderRepo.MarkAsFailed(ctx, reminder.ID, reminder.Lease(), cause.Error())
+}
+
+// retryDelay returns the backoff before attempt+1: 30s, 1m, 2m, ... capped at 30m
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// Snooze sends an already delivered reminder again after d
+func (r *ReminderUsecase) Snooze(ctx context.Context, userID, reminderID int64, d time.Duration) error {
+	return r.reminderRepo.Snooze(ctx, reminderID, userID, time.Now().Add(d))
+}
+
+func (r *ReminderUsecase) Acknowledge(ctx context.Context, userID, reminderID int64) error {
+	return r.reminderRepo.MarkAsAcknowledged(ctx, reminderID, userID)
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+type SettingsUsecase struct {
+	settingsRepo domain.SettingsRepository
+}
+
+func NewSettingsUsecase(settingsRepo domain.SettingsRepository) *SettingsUsecase {
+	return &SettingsUsecase{
+		settingsRepo: settingsRepo,
+	}
+}
+
+// Get returns stored settings or defaults if the user never changed them
+func (s *SettingsUsecase) Get(ctx context.Context, userID int64) (*domain.UserSettings, error) {
+	return settingsOrDefault(ctx, s.settingsRepo, userID)
+}
+
+// Update applies fn to the current settings, validates and stores the result
+func (s *SettingsUsecase) Update(ctx context.Context, userID int64, fn func(st *domain.UserSettings)) (*domain.UserSettings, error) {
+	st, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fn(st)
+	st.UserID = userID
+	if err := st.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.settingsRepo.Upsert(ctx, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func settingsOrDefault(ctx context.Context, repo domain.SettingsRepository, userID int64) (*domain.UserSettings, error) {
+	st, err := repo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSettingsNotFound) {
+			return domain.DefaultUserSettings(userID), nil
+		}
+		return nil, err
+	}
+	return st, nil
+}
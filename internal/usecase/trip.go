@@ -3,34 +3,81 @@ package usecase
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
 	"github.com/X1ag/TravelScheduler/internal/utils"
 )
 
 
 
 var (
-	ErrDepartureTimeEmpty = errors.New("Время отправления не может быть пустым")
-	ErrToPlatformEmpty = errors.New("Платформа назначения не может быть пустой")
+	ErrDepartureTimeEmpty = domain.NewError("trip.departure_time_empty", "departure time is empty")
+	ErrToPlatformEmpty = domain.NewError("trip.to_platform_empty", "destination platform is empty")
 )
 
 type TripUsecase struct {
 	tripRepo domain.TripRepository
 	reminderRepo domain.ReminderRepository
+	settingsRepo domain.SettingsRepository
+	stationRepo domain.StationRepository
 	yandex domain.ScheduleProvider
+	tx domain.Transactor
 }
 
-func NewTripUsecase(tr domain.TripRepository, rr domain.ReminderRepository, yandex domain.ScheduleProvider) *TripUsecase {
+func NewTripUsecase(tr domain.TripRepository, rr domain.ReminderRepository, sr domain.SettingsRepository, str domain.StationRepository, yandex domain.ScheduleProvider, tx domain.Transactor) *TripUsecase {
 	return &TripUsecase{
+		tx: tx,
 		tripRepo: tr,
 		yandex: yandex,
 		reminderRepo: rr,
+		settingsRepo: sr,
+		stationRepo: str,
 	}
 }
 
+// FindStation resolves a station code, looking at the built-in list first
+// and then at stations imported with import-stations
+func (t *TripUsecase) FindStation(ctx context.Context, code string) (utils.StationOption, bool) {
+	if station, found := utils.GetStationByCode(code); found {
+		return station, true
+	}
+	if st, err := t.stationRepo.GetByCode(ctx, code); err == nil {
+		return utils.StationOption{Code: st.Code, DisplayName: st.Name}, true
+	}
+	return utils.StationOption{}, false
+}
+
+// SearchStations returns up to limit stations whose name matches the typed
+// query, built-in ones first, so the user can pick the one they meant. If
+// imported stations can't be searched, the built-in matches are returned
+// with the error.
+func (t *TripUsecase) SearchStations(ctx context.Context, query string, limit int) ([]utils.StationOption, error) {
+	matches := utils.SearchStations(query)
+	if len(matches) >= limit {
+		return matches[:limit], nil
+	}
+	imported, err := t.stationRepo.Search(ctx, query, limit)
+	if err != nil {
+		return matches, err
+	}
+	seen := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		seen[m.Code] = true
+	}
+	for _, st := range imported {
+		if len(matches) == limit {
+			break
+		}
+		if !seen[st.Code] {
+			seen[st.Code] = true
+			matches = append(matches, utils.StationOption{Code: st.Code, DisplayName: st.Name})
+		}
+	}
+	return matches, nil
+}
+
 func (t *TripUsecase) Create(ctx context.Context, tr *domain.Trip) error {
 	if tr.DepartureTime.IsZero() {
 		return ErrDepartureTimeEmpty	
@@ -45,6 +92,88 @@ func (t *TripUsecase) GetByUserID(ctx context.Context, userID int64) ([]*domain.
 	return t.tripRepo.GetByUserID(ctx, userID)
 }
 
+// HistoryPageSize is how many trips one page of the history shows
+const HistoryPageSize = 5
+
+// TripPage is one page of upcoming or past trips
+type TripPage struct {
+	Trips []*domain.Trip
+	Page  int
+	Pages int
+	Total int
+	// Upcoming and Past count all trips, to tell where the other tab leads
+	Upcoming int
+	Past     int
+}
+
+// History returns a page of the user's upcoming or past trips. Out-of-range
+// pages are clamped, so stale buttons still show something.
+func (t *TripUsecase) History(ctx context.Context, userID int64, past bool, page int, now time.Time) (*TripPage, error) {
+	upcoming, pastCount, err := t.tripRepo.CountByUser(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	result := &TripPage{Upcoming: upcoming, Past: pastCount, Total: upcoming}
+	if past {
+		result.Total = pastCount
+	}
+	result.Pages = max(1, (result.Total+HistoryPageSize-1)/HistoryPageSize)
+	result.Page = min(max(page, 0), result.Pages-1)
+
+	offset := result.Page * HistoryPageSize
+	if past {
+		result.Trips, err = t.tripRepo.GetPast(ctx, userID, now, HistoryPageSize, offset)
+	} else {
+		result.Trips, err = t.tripRepo.GetUpcoming(ctx, userID, now, HistoryPageSize, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+const (
+	statsMonths = 6
+	statsRoutes = 3
+)
+
+// UserStats is the personal /mystats report over past trips
+type UserStats struct {
+	Totals domain.TripTotals
+	// Months covers the last statsMonths months including the current one,
+	// oldest first, with zero for months without trips
+	Months []domain.TripMonthStat
+	Routes []domain.RouteStat
+}
+
+func (t *TripUsecase) Stats(ctx context.Context, userID int64, now time.Time, loc *time.Location) (*UserStats, error) {
+	totals, err := t.tripRepo.Totals(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	local := now.In(loc)
+	first := time.Date(local.Year(), local.Month()-statsMonths+1, 1, 0, 0, 0, 0, loc)
+	counts, err := t.tripRepo.CountByMonth(ctx, userID, first, now, loc)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := t.tripRepo.TopRoutes(ctx, userID, now, statsRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make([]domain.TripMonthStat, statsMonths)
+	for i := range months {
+		months[i].Month = first.AddDate(0, i, 0)
+		for _, c := range counts {
+			if c.Month.Equal(months[i].Month) {
+				months[i].Trips = c.Trips
+			}
+		}
+	}
+	return &UserStats{Totals: totals, Months: months, Routes: routes}, nil
+}
+
 func (t *TripUsecase) Search(ctx context.Context, from, to string, startDate time.Time) ([]*domain.Schedule, error) {
 	allOptions, err := t.yandex.GetNextTrains(ctx, from, to, startDate)
 	if err != nil {
@@ -78,19 +207,227 @@ func (t *TripUsecase) filteredOptions(options []*domain.Schedule, date time.Time
 	return result 
 }
 
-func (t *TripUsecase) ConfirmTrip(ctx context.Context, tr *domain.Trip) error {
-	if err := t.Create(ctx, tr); err != nil {
-		return err
+// ConfirmTrip books the trip and schedules its reminder. requestID
+// identifies the confirmation (the callback query ID): repeating it returns
+// the trip created the first time. Booking a train the user is already on
+// fills tr with the existing trip and returns domain.ErrTripAlreadyBooked.
+func (t *TripUsecase) ConfirmTrip(ctx context.Context, tr *domain.Trip, requestID string) error {
+	if requestID != "" {
+		existing, err := t.tripRepo.GetByRequestID(ctx, requestID)
+		if err == nil {
+			*tr = *existing
+			return nil
+		}
+		if !errors.Is(err, domain.ErrTripNotFound) {
+			return err
+		}
 	}
-	station, exists := utils.GetStationByCode(tr.From)
+	tr.RequestID = requestID
+
+	station, exists := t.FindStation(ctx, tr.From)
 	if !exists {
-		return errors.New("Станция отправления не найдена") 
+		return domain.ErrStationNotFound
+	}
+	if tr.FromName == "" {
+		tr.FromName = station.DisplayName
+	}
+	if tr.ToName == "" {
+		if to, ok := t.FindStation(ctx, tr.To); ok {
+			tr.ToName = to.DisplayName
+		}
 	}
-	return t.reminderRepo.Create(ctx, &domain.Reminder{
+	// The trip and its reminder are created together, so a failed reminder
+	// insert doesn't leave a trip the user is never reminded about
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := t.Create(ctx, tr); err != nil {
+			return err
+		}
+		settings, err := settingsOrDefault(ctx, t.settingsRepo, tr.UserID)
+		if err != nil {
+			return err
+		}
+		return t.reminderRepo.Create(ctx, tripReminder(tr, tr.UserID, settings))
+	})
+	if err != nil {
+		tr.ID = 0 // rolled back
+		if errors.Is(err, domain.ErrTripAlreadyExists) {
+			// Looked up after the rollback, the failed transaction can't be queried
+			return t.alreadyBooked(ctx, tr)
+		}
+		return err
+	}
+	return nil
+}
+
+// tripReminder is the reminder of one traveller, the owner or a companion
+func tripReminder(tr *domain.Trip, userID int64, settings *domain.UserSettings) *domain.Reminder {
+	return &domain.Reminder{
 		TripID:    tr.ID,
-		UserID:    tr.UserID,
-		Message:   fmt.Sprintf("Ваша поездка со станции %s начнется через 30 минут! Не опоздайте!", station),
-		TriggerAt: tr.DepartureTime.Add(-30 * time.Minute),
-		Status: string(domain.StatusPending),
+		UserID:    userID,
+		Message:   i18n.For(settings.Language).N("reminder.trip", settings.ReminderLeadMinutes, tr.FromDisplay(), settings.ReminderLeadMinutes),
+		TriggerAt: tr.DepartureTime.Add(-settings.ReminderLead()),
+		Status:    string(domain.StatusPending),
+	}
+}
+
+// ChangeTrain moves a booked trip to another train of the same route. The
+// trip, its audit record and its reminders are updated in one transaction.
+func (t *TripUsecase) ChangeTrain(ctx context.Context, userID, tripID int64, opt *domain.Schedule, now time.Time) (*domain.Trip, error) {
+	var tr *domain.Trip
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Locked so a concurrent change can't leave stale old values in the audit row
+		locked, err := t.tripRepo.GetByIDForUpdate(ctx, tripID)
+		if err != nil {
+			return err
+		}
+		if locked.UserID != userID {
+			return domain.ErrTripNotFound
+		}
+		if !locked.DepartureTime.After(now) {
+			return domain.ErrTripDeparted
+		}
+		if opt.From != locked.From || opt.To != locked.To {
+			return domain.ErrTripRouteMismatch
+		}
+		old := *locked
+		tr = locked
+		tr.ApplySchedule(opt)
+
+		if err := t.tripRepo.UpdateSchedule(ctx, tr); err != nil {
+			return err
+		}
+		err = t.tripRepo.RecordChange(ctx, &domain.TripChange{
+			TripID:         tr.ID,
+			UserID:         tr.UserID,
+			OldTrainNumber: old.TrainNumber,
+			OldTrainTitle:  old.TrainTitle,
+			OldDeparture:   old.DepartureTime,
+			OldArrival:     old.ArrivalTime,
+			NewTrainNumber: tr.TrainNumber,
+			NewTrainTitle:  tr.TrainTitle,
+			NewDeparture:   tr.DepartureTime,
+			NewArrival:     tr.ArrivalTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := t.moveReminder(ctx, tr, tr.UserID, now); err != nil {
+			return err
+		}
+		// Companions who joined the trip are on the new train as well
+		participants, err := t.tripRepo.GetParticipants(ctx, tr.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range participants {
+			if err := t.moveReminder(ctx, tr, p.ID, now); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if errors.Is(err, domain.ErrTripAlreadyExists) {
+		return nil, domain.ErrTripAlreadyBooked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// TripChanges returns the audit log of the user's trip, oldest first
+func (t *TripUsecase) TripChanges(ctx context.Context, userID, tripID int64) ([]*domain.TripChange, error) {
+	if _, err := t.GetTrip(ctx, userID, tripID); err != nil {
+		return nil, err
+	}
+	return t.tripRepo.GetChanges(ctx, tripID)
+}
+
+// alreadyBooked resolves a unique violation to the trip that caused it
+func (t *TripUsecase) alreadyBooked(ctx context.Context, tr *domain.Trip) error {
+	requestID := tr.RequestID
+	if requestID != "" {
+		// The same confirmation raced with itself
+		if existing, err := t.tripRepo.GetByRequestID(ctx, requestID); err == nil {
+			*tr = *existing
+			return nil
+		}
+	}
+	existing, err := t.tripRepo.GetByTrain(ctx, tr.UserID, tr.TrainNumber, tr.DepartureTime)
+	if err != nil {
+		return err
+	}
+	*tr = *existing
+	return domain.ErrTripAlreadyBooked
+}
+
+// moveReminder moves the user's undelivered reminder to the trip's new
+// departure, or creates one if it was already delivered
+func (t *TripUsecase) moveReminder(ctx context.Context, tr *domain.Trip, userID int64, now time.Time) error {
+	settings, err := settingsOrDefault(ctx, t.settingsRepo, userID)
+	if err != nil {
+		return err
+	}
+	reminder := tripReminder(tr, userID, settings)
+	moved, err := t.reminderRepo.RescheduleByTrip(ctx, tr.ID, userID, reminder.TriggerAt)
+	if err != nil || moved > 0 {
+		return err
+	}
+	// The old reminder was already sent; remind about the new train too
+	if reminder.TriggerAt.After(now) {
+		return t.reminderRepo.Create(ctx, reminder)
+	}
+	return nil
+}
+
+// JoinTrip adds a companion to a trip booked in a group chat. They get their
+// own reminder, with their lead time and language.
+func (t *TripUsecase) JoinTrip(ctx context.Context, tripID, userID int64, now time.Time) (*domain.Trip, error) {
+	tr, err := t.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if tr.ChatID == 0 {
+		return nil, domain.ErrTripNotFound
+	}
+	if tr.UserID == userID {
+		return nil, domain.ErrTripOwnJoin
+	}
+	if !tr.DepartureTime.After(now) {
+		return nil, domain.ErrTripDeparted
+	}
+
+	err = t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := t.tripRepo.AddParticipant(ctx, tr.ID, userID); err != nil {
+			return err
+		}
+		settings, err := settingsOrDefault(ctx, t.settingsRepo, userID)
+		if err != nil {
+			return err
+		}
+		return t.reminderRepo.Create(ctx, tripReminder(tr, userID, settings))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// GetByID returns any trip; unlike GetTrip it doesn't check the owner, so it
+// is only for internal use such as routing reminders
+func (t *TripUsecase) GetByID(ctx context.Context, tripID int64) (*domain.Trip, error) {
+	return t.tripRepo.GetByID(ctx, tripID)
+}
+
+// GetTrip returns the user's trip; trips of other users are reported as not found
+func (t *TripUsecase) GetTrip(ctx context.Context, userID, tripID int64) (*domain.Trip, error) {
+	trip, err := t.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip.UserID != userID {
+		return nil, domain.ErrTripNotFound
+	}
+	return trip, nil
 }
@@ -2,22 +2,27 @@ package usecase
 
 import (
 	"context"
-	"errors"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
 )
 
 var (
-	ErrTelegramIDEmpty = errors.New("Telegram ID не может быть пустым")
+	ErrTelegramIDEmpty = domain.NewError("user.telegram_id_empty", "telegram id is empty")
 )
 
 type UserUsecase struct {
-	userRepo domain.UserRepository
+	userRepo     domain.UserRepository
+	reminderRepo domain.ReminderRepository
+	tripRepo     domain.TripRepository
+	tx           domain.Transactor
 }
 
-func NewUserUsecase(userRepo domain.UserRepository) *UserUsecase {
+func NewUserUsecase(userRepo domain.UserRepository, reminderRepo domain.ReminderRepository, tripRepo domain.TripRepository, tx domain.Transactor) *UserUsecase {
 	return &UserUsecase{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		reminderRepo: reminderRepo,
+		tripRepo:     tripRepo,
+		tx:           tx,
 	}
 }
 
@@ -25,6 +30,7 @@ func (u *UserUsecase) Create(ctx context.Context, user *domain.User) error {
 	if user.TelegramID == 0 {
 		return ErrTelegramIDEmpty
 	}
+	user.IsActive = true
 	return u.userRepo.Create(ctx, user)
 }
 
@@ -34,4 +40,45 @@ func (u *UserUsecase) GetUserByTelegramID(ctx context.Context, telegramID int64)
 
 func (u *UserUsecase) GetUserByID(ctx context.Context, userID int64) (*domain.User, error) {
 	return u.userRepo.GetByID(ctx, userID)
-}
\ No newline at end of file
+}
+
+// Deactivate stops all deliveries to a user who blocked the bot
+func (u *UserUsecase) Deactivate(ctx context.Context, userID int64) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.userRepo.SetActive(ctx, userID, false); err != nil {
+			return err
+		}
+		return u.reminderRepo.CancelPendingByUserID(ctx, userID)
+	})
+}
+
+// DeleteAccount erases the user and everything stored about them. Cancelling
+// pending reminders in the same transaction locks them, so a worker claiming
+// concurrently skips them instead of delivering to a deleted user. Group
+// trips with companions are handed over to them first, so deleting the
+// account doesn't cancel their trips and reminders.
+func (u *UserUsecase) DeleteAccount(ctx context.Context, userID int64) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.reminderRepo.CancelPendingByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := u.tripRepo.HandOver(ctx, userID); err != nil {
+			return err
+		}
+		return u.userRepo.Delete(ctx, userID)
+	})
+}
+
+// SetChatID remembers the user's private chat with the bot
+func (u *UserUsecase) SetChatID(ctx context.Context, userID, chatID int64) error {
+	return u.userRepo.SetChatID(ctx, userID, chatID)
+}
+
+// Activate re-enables deliveries after the user came back
+func (u *UserUsecase) Activate(ctx context.Context, userID int64) error {
+	return u.userRepo.SetActive(ctx, userID, true)
+}
+
+func (u *UserUsecase) List(ctx context.Context) ([]*domain.User, error) {
+	return u.userRepo.List(ctx)
+}
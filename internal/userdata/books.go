@@ -0,0 +1,159 @@
+package userdata
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+var (
+	ErrImportEmpty     = domain.NewError("import.empty", "import file is empty")
+	ErrImportNoTitle   = domain.NewError("import.no_title", "import file has no title column")
+	ErrImportTooMany   = domain.NewError("import.too_many", "import file has too many rows")
+	ErrImportBadNumber = domain.NewError("import.bad_number", "page count is not a number")
+	ErrImportBadRow    = domain.NewError("import.bad_row", "malformed CSV row")
+)
+
+// MaxImportBooks caps the rows read from one import file
+const MaxImportBooks = 1000
+
+const bom = "\uFEFF"
+
+// BookRow is one parsed data row; Err is set when the row can't be read
+type BookRow struct {
+	Line int // 1-based line in the file, the header is line 1
+	Book domain.Book
+	Err  error
+}
+
+// Column names recognized in the header, lower-cased. The first group is our
+// own export, then Goodreads and common spreadsheet headings.
+var (
+	titleColumns   = []string{"name", "book_name", "title", "название", "книга"}
+	authorColumns  = []string{"author", "автор"}
+	pagesColumns   = []string{"total_pages", "number of pages", "pages", "страниц", "страницы"}
+	currentColumns = []string{"current_pages", "pages_read", "прочитано"}
+	// Goodreads marks finished books with Exclusive Shelf = read
+	shelfColumns = []string{"exclusive shelf"}
+)
+
+// ReadBooks parses a CSV with a header row, comma or semicolon separated, as
+// exported by WriteBooksCSV or Goodreads. Rows are not validated beyond
+// their numbers; that is up to the caller.
+func ReadBooks(r io.Reader) ([]BookRow, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	header, _, _ := strings.Cut(strings.TrimPrefix(string(first), bom), "\n")
+	if strings.TrimSpace(header) == "" {
+		return nil, ErrImportEmpty
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		cr.Comma = ';'
+	}
+
+	head, err := cr.Read()
+	if err != nil {
+		return nil, ErrImportEmpty
+	}
+	cols := make(map[string]int, len(head))
+	for i, name := range head {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, bom)))
+		if _, dup := cols[name]; !dup {
+			cols[name] = i
+		}
+	}
+	title := column(cols, titleColumns)
+	if title < 0 {
+		return nil, ErrImportNoTitle
+	}
+	author := column(cols, authorColumns)
+	pages := column(cols, pagesColumns)
+	current := column(cols, currentColumns)
+	shelf := column(cols, shelfColumns)
+
+	var rows []BookRow
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return nil, err
+			}
+			rows = append(rows, BookRow{Line: perr.Line, Err: fmt.Errorf("%w: %v", ErrImportBadRow, perr.Err)})
+			continue
+		}
+		if blank(rec) {
+			continue
+		}
+		if len(rows) == MaxImportBooks {
+			return nil, ErrImportTooMany
+		}
+
+		row := BookRow{Line: line, Book: domain.Book{
+			BookName: field(rec, title),
+			Author:   field(rec, author),
+		}}
+		if row.Book.TotalPages, row.Err = number(field(rec, pages)); row.Err == nil {
+			row.Book.CurrentPages, row.Err = number(field(rec, current))
+		}
+		if strings.EqualFold(field(rec, shelf), "read") {
+			row.Book.CurrentPages = row.Book.TotalPages
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func column(cols map[string]int, names []string) int {
+	for _, n := range names {
+		if i, ok := cols[n]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func field(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// number parses a page count; empty means zero
+func number(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ErrImportBadNumber
+	}
+	return n, nil
+}
+
+func blank(rec []string) bool {
+	for _, f := range rec {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
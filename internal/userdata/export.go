@@ -0,0 +1,178 @@
+// Package userdata converts a user's trips, reminders and books to and from
+// portable CSV and JSON files
+package userdata
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+)
+
+// Trip is the exported form of a trip
+type Trip struct {
+	ID              int64  `json:"id"`
+	FromStation     string `json:"from_station"`
+	FromName        string `json:"from_name"`
+	ToStation       string `json:"to_station"`
+	ToName          string `json:"to_name"`
+	TrainNumber     string `json:"train_number,omitempty"`
+	TrainTitle      string `json:"train_title,omitempty"`
+	DepartureTime   string `json:"departure_time"`
+	ArrivalTime     string `json:"arrival_time,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	BookID          *int64 `json:"book_id,omitempty"`
+	PagesRead       int    `json:"pages_read"`
+	Joined          bool   `json:"joined,omitempty"` // booked by a companion in a group chat
+}
+
+// Reminder is the exported form of a reminder
+type Reminder struct {
+	ID        int64  `json:"id"`
+	TripID    int64  `json:"trip_id,omitempty"`
+	Message   string `json:"message"`
+	TriggerAt string `json:"trigger_at"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+}
+
+// Book is the exported form of a book; its CSV can be imported back
+type Book struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Author       string `json:"author"`
+	TotalPages   int    `json:"total_pages"`
+	CurrentPages int    `json:"current_pages"`
+}
+
+// Archive is everything exported for one user
+type Archive struct {
+	ExportedAt string     `json:"exported_at"`
+	Trips      []Trip     `json:"trips"`
+	Reminders  []Reminder `json:"reminders"`
+	Books      []Book     `json:"books"`
+}
+
+// NewArchive converts userID's domain objects, formatting times in loc as
+// RFC 3339; pagesRead maps trip IDs to the pages the user read on them
+func NewArchive(userID int64, trips []*domain.Trip, pagesRead map[int64]int, reminders []*domain.Reminder, books []*domain.Book, loc *time.Location, now time.Time) *Archive {
+	a := &Archive{
+		ExportedAt: formatTime(now, loc),
+		Trips:      make([]Trip, 0, len(trips)),
+		Reminders:  make([]Reminder, 0, len(reminders)),
+		Books:      make([]Book, 0, len(books)),
+	}
+	for _, t := range trips {
+		a.Trips = append(a.Trips, Trip{
+			ID:              t.ID,
+			FromStation:     t.From,
+			FromName:        t.FromName,
+			ToStation:       t.To,
+			ToName:          t.ToName,
+			TrainNumber:     t.TrainNumber,
+			TrainTitle:      t.TrainTitle,
+			DepartureTime:   formatTime(t.DepartureTime, loc),
+			ArrivalTime:     formatTime(t.ArrivalTime, loc),
+			DurationMinutes: int(t.Duration.Minutes()),
+			BookID:          t.BookID,
+			PagesRead:       pagesRead[t.ID],
+			Joined:          t.UserID != userID,
+		})
+	}
+	for _, r := range reminders {
+		a.Reminders = append(a.Reminders, Reminder{
+			ID:        r.ID,
+			TripID:    r.TripID,
+			Message:   r.Message,
+			TriggerAt: formatTime(r.TriggerAt, loc),
+			Status:    r.Status,
+			Attempts:  r.Attempts,
+		})
+	}
+	for _, b := range books {
+		a.Books = append(a.Books, Book{
+			ID:           b.ID,
+			Name:         b.BookName,
+			Author:       b.Author,
+			TotalPages:   b.TotalPages,
+			CurrentPages: b.CurrentPages,
+		})
+	}
+	return a
+}
+
+// WriteJSON writes the whole archive as one indented JSON document
+func (a *Archive) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// WriteTripsCSV writes the trips with a header row
+func (a *Archive) WriteTripsCSV(w io.Writer) error {
+	rows := [][]string{{"id", "from_station", "from_name", "to_station", "to_name", "train_number", "train_title",
+		"departure_time", "arrival_time", "duration_minutes", "book_id", "pages_read", "joined"}}
+	for _, t := range a.Trips {
+		rows = append(rows, []string{itoa(t.ID), t.FromStation, t.FromName, t.ToStation, t.ToName, t.TrainNumber, t.TrainTitle,
+			t.DepartureTime, t.ArrivalTime, strconv.Itoa(t.DurationMinutes), optionalPtr(t.BookID), strconv.Itoa(t.PagesRead), strconv.FormatBool(t.Joined)})
+	}
+	return writeCSV(w, rows)
+}
+
+// WriteRemindersCSV writes the reminders with a header row
+func (a *Archive) WriteRemindersCSV(w io.Writer) error {
+	rows := [][]string{{"id", "trip_id", "message", "trigger_at", "status", "attempts"}}
+	for _, r := range a.Reminders {
+		rows = append(rows, []string{itoa(r.ID), optionalID(r.TripID), r.Message, r.TriggerAt, r.Status, strconv.Itoa(r.Attempts)})
+	}
+	return writeCSV(w, rows)
+}
+
+// WriteBooksCSV writes the books in the format ReadBooks accepts
+func (a *Archive) WriteBooksCSV(w io.Writer) error {
+	rows := [][]string{{"id", "name", "author", "total_pages", "current_pages"}}
+	for _, b := range a.Books {
+		rows = append(rows, []string{itoa(b.ID), b.Name, b.Author, strconv.Itoa(b.TotalPages), strconv.Itoa(b.CurrentPages)})
+	}
+	return writeCSV(w, rows)
+}
+
+// writeCSV prefixes a UTF-8 BOM so spreadsheet apps detect the encoding
+func writeCSV(w io.Writer, rows [][]string) error {
+	if _, err := io.WriteString(w, bom); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func formatTime(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func optionalPtr(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return itoa(*id)
+}
+
+func optionalID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return itoa(id)
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+	user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	reminder_lead_minutes INT NOT NULL DEFAULT 30,
+	language VARCHAR(8) NOT NULL DEFAULT 'ru',
+	timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow',
+
+	home_station TEXT,
+	work_station TEXT,
+
+	quiet_hours_start SMALLINT,
+	quiet_hours_end SMALLINT,
+
+	show_details BOOLEAN NOT NULL DEFAULT TRUE,
+
+	CONSTRAINT check_reminder_lead CHECK (reminder_lead_minutes > 0),
+	CONSTRAINT check_quiet_hours CHECK (
+		(quiet_hours_start IS NULL AND quiet_hours_end IS NULL) OR
+		(quiet_hours_start BETWEEN 0 AND 23 AND quiet_hours_end BETWEEN 0 AND 23)
+	)
+);
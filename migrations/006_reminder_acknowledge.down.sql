@@ -0,0 +1,7 @@
+UPDATE reminders SET status = 'sent' WHERE status = 'acknowledged';
+ALTER TABLE reminders DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE reminders ADD CONSTRAINT check_status 
+CHECK (status IN ('pending', 'sent', 'failed', 'cancelled'));
+
+DELETE FROM reminders WHERE trip_id IS NULL;
+ALTER TABLE reminders ALTER COLUMN trip_id SET NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE reminders ALTER COLUMN trip_id DROP NOT NULL;
+
+ALTER TABLE reminders DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE reminders ADD CONSTRAINT check_status 
+CHECK (status IN ('pending', 'sent', 'failed', 'cancelled', 'acknowledged'));
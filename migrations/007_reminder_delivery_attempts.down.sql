@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
+
+ALTER TABLE reminders DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE reminders DROP COLUMN IF EXISTS last_error;
+ALTER TABLE reminders DROP COLUMN IF EXISTS attempts;
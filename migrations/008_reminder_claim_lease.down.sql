@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_reminders_locked_until_processing;
+
+UPDATE reminders SET status = 'pending' WHERE status = 'processing';
+ALTER TABLE reminders DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE reminders ADD CONSTRAINT check_status 
+CHECK (status IN ('pending', 'sent', 'failed', 'cancelled', 'acknowledged'));
+
+ALTER TABLE reminders DROP COLUMN IF EXISTS locked_until;
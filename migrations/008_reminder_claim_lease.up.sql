@@ -0,0 +1,9 @@
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE reminders DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE reminders ADD CONSTRAINT check_status 
+CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'cancelled', 'acknowledged'));
+
+CREATE INDEX IF NOT EXISTS idx_reminders_locked_until_processing 
+ON reminders(locked_until) 
+WHERE status = 'processing';
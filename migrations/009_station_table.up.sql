@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS stations (
+	code TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stations_name_lower ON stations(LOWER(name));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_trips_user_departure;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS pages_read;
+ALTER TABLE trips DROP COLUMN IF EXISTS duration_seconds;
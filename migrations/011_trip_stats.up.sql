@@ -0,0 +1,5 @@
+-- Train time of the selected schedule, NULL for trips created before it was stored
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS duration_seconds INT;
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS pages_read INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_trips_user_departure ON trips(user_id, departure_time);
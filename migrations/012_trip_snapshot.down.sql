@@ -0,0 +1,5 @@
+ALTER TABLE trips DROP COLUMN IF EXISTS arrival_time;
+ALTER TABLE trips DROP COLUMN IF EXISTS train_title;
+ALTER TABLE trips DROP COLUMN IF EXISTS train_number;
+ALTER TABLE trips DROP COLUMN IF EXISTS to_name;
+ALTER TABLE trips DROP COLUMN IF EXISTS from_name;
@@ -0,0 +1,9 @@
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS from_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS to_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS train_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS train_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS arrival_time TIMESTAMP WITH TIME ZONE;
+
+-- Older trips only have codes; take names of imported stations where known
+UPDATE trips t SET from_name = s.name FROM stations s WHERE t.from_name = '' AND s.code = t.from_station;
+UPDATE trips t SET to_name = s.name FROM stations s WHERE t.to_name = '' AND s.code = t.to_station;
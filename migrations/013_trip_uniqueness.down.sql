@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS uq_trips_request_id;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS request_id;
+
+DROP INDEX IF EXISTS uq_trips_user_train_departure;
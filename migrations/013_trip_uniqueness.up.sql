@@ -0,0 +1,15 @@
+-- Double taps on a train button could book it twice; keep the first trip
+DELETE FROM trips t USING trips d
+WHERE t.user_id = d.user_id AND t.train_number = d.train_number AND t.departure_time = d.departure_time
+	AND t.train_number <> '' AND t.id > d.id;
+
+-- Trips saved before the train snapshot have no train number and are not checked
+CREATE UNIQUE INDEX IF NOT EXISTS uq_trips_user_train_departure
+ON trips(user_id, train_number, departure_time)
+WHERE train_number <> '';
+
+-- Telegram callback query ID of the confirmation, so a redelivered update
+-- returns the trip it already created
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS request_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_trips_request_id ON trips(request_id);
@@ -0,0 +1,20 @@
+-- Audit log of train changes; the first row of a trip holds the original booking
+CREATE TABLE IF NOT EXISTS trip_changes (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	trip_id BIGINT NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+	old_train_number TEXT NOT NULL,
+	old_train_title TEXT NOT NULL,
+	old_departure_time TIMESTAMP WITH TIME ZONE NOT NULL,
+	old_arrival_time TIMESTAMP WITH TIME ZONE,
+
+	new_train_number TEXT NOT NULL,
+	new_train_title TEXT NOT NULL,
+	new_departure_time TIMESTAMP WITH TIME ZONE NOT NULL,
+	new_arrival_time TIMESTAMP WITH TIME ZONE,
+
+	changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_changes_trip_id ON trip_changes(trip_id);
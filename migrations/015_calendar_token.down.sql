@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uq_users_calendar_token;
+
+ALTER TABLE users DROP COLUMN IF EXISTS calendar_token;
@@ -0,0 +1,4 @@
+-- Secret part of the user's calendar subscription URL, NULL when not issued
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_token TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_calendar_token ON users(calendar_token);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS uq_books_user_title;
+
+ALTER TABLE books ADD CONSTRAINT books_book_name_key UNIQUE (book_name);
+
+ALTER TABLE books DROP COLUMN IF EXISTS author;
@@ -0,0 +1,7 @@
+-- Book.Author was never stored; imports need it
+ALTER TABLE books ADD COLUMN IF NOT EXISTS author TEXT NOT NULL DEFAULT '';
+
+-- Titles were unique across all users; make them unique per user and author
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_book_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_books_user_title ON books(user_id, lower(book_name), lower(author));
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS trip_participants;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS chat_id;
+
+UPDATE users SET chat_id = telegram_id WHERE chat_id IS NULL;
+ALTER TABLE users ALTER COLUMN chat_id SET NOT NULL;
@@ -0,0 +1,18 @@
+-- Private chat with the bot; NULL for users who only used it in groups.
+-- Everyone registered before groups were supported wrote to the bot
+-- privately, where the chat ID equals the user ID.
+ALTER TABLE users ALTER COLUMN chat_id DROP NOT NULL;
+UPDATE users SET chat_id = telegram_id WHERE chat_id IS NULL;
+
+-- Group chat the trip was booked in, NULL for private chats
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS chat_id BIGINT;
+
+-- Travel companions who joined a trip booked in a group
+CREATE TABLE IF NOT EXISTS trip_participants (
+	trip_id BIGINT NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (trip_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_participants_user_id ON trip_participants(user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS trip_reading;
+
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS pages_read INT NOT NULL DEFAULT 0;
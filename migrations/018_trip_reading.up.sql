@@ -0,0 +1,15 @@
+-- Pages read during a trip, recorded when book progress is saved while the
+-- trip is under way. Kept per reader, so every companion of a group trip
+-- gets their own pages; replaces trips.pages_read, which was never written.
+CREATE TABLE IF NOT EXISTS trip_reading (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	trip_id BIGINT NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	book_id BIGINT REFERENCES books(id) ON DELETE SET NULL,
+	pages INT NOT NULL CHECK (pages > 0),
+	read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_reading_user_id ON trip_reading(user_id);
+
+ALTER TABLE trips DROP COLUMN IF EXISTS pages_read;
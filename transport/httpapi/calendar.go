@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/calendar"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/usecase"
+)
+
+// CalendarFeed renders the calendar owned by a subscription token
+type CalendarFeed func(ctx context.Context, token string, now time.Time) ([]byte, error)
+
+// HandleCalendar serves subscription feeds at GET <prefix>{token}.ics
+func (s *Server) HandleCalendar(prefix string, feed CalendarFeed) {
+	s.mux.Handle("GET "+prefix+"{file}", calendarHandler(feed))
+}
+
+func calendarHandler(feed CalendarFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSuffix(r.PathValue("file"), ".ics")
+		data, err := feed(r.Context(), token, time.Now())
+		if errors.Is(err, usecase.ErrCalendarNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			// The path holds the secret token, keep it out of the logs
+			logging.FromContext(r.Context()).Error("calendar feed failed", logging.Err(err))
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", calendar.ContentType)
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		w.Write(data)
+	})
+}
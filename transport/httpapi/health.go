@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 3 * time.Second
+
+// HealthCheck is one dependency reported by /healthz and /readyz
+type HealthCheck struct {
+	Name string
+	// Liveness checks also run for /healthz; failing one means the process
+	// is stuck and should be restarted. Others only affect /readyz.
+	Liveness bool
+	// Optional checks are reported but never fail the endpoint
+	Optional bool
+	// Check returns details for the response and an error if unhealthy
+	Check func(ctx context.Context) (map[string]any, error)
+}
+
+type checkResult struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// HandleHealth serves /healthz and /readyz. Both answer 200 when all their
+// required checks pass and 503 otherwise.
+func (s *Server) HandleHealth(checks ...HealthCheck) {
+	var liveness []HealthCheck
+	for _, c := range checks {
+		if c.Liveness {
+			liveness = append(liveness, c)
+		}
+	}
+	s.mux.Handle("GET /healthz", healthHandler(liveness))
+	s.mux.Handle("GET /readyz", healthHandler(checks))
+}
+
+func healthHandler(checks []HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		resp := runChecks(ctx, checks)
+		code := http.StatusOK
+		if resp.Status == "fail" {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func runChecks(ctx context.Context, checks []HealthCheck) healthResponse {
+	results := make([]checkResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			details, err := c.Check(ctx)
+			results[i] = checkResult{Status: "ok", Details: details}
+			if err != nil {
+				results[i].Status = "fail"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := healthResponse{Status: "ok", Checks: make(map[string]checkResult, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		if res.Status == "fail" {
+			if !c.Optional {
+				resp.Status = "fail"
+			} else if resp.Status == "ok" {
+				resp.Status = "degraded"
+			}
+		}
+		resp.Checks[c.Name] = res
+	}
+	return resp
+}
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server is the bot's HTTP endpoint for operational handlers
+type Server struct {
+	srv *http.Server
+	mux *http.ServeMux
+}
+
+func NewServer(addr string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) Addr() string {
+	return s.srv.Addr
+}
+
+// Start binds the listen address and serves in the background
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		slog.Info("http server listening", "addr", ln.Addr().String())
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops accepting connections and waits for active requests
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
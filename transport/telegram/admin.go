@@ -0,0 +1,295 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const adminListLimit = 20
+
+// adminLocalizer renders the shared bits of admin replies; admin commands are
+// for operators and only exist in Russian
+var adminLocalizer = i18n.For(i18n.Default)
+
+// registerAdminCommand registers a command that may take arguments. Others
+// get the regular text reply, so admin commands stay invisible to them.
+func (b *Bot) registerAdminCommand(command string, handler bot.HandlerFunc) {
+	gated := func(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+		if update.Message.From == nil || !b.adminUC.IsAdmin(update.Message.From.ID) {
+			logging.FromContext(ctx).Warn("admin command from non-admin", "command", command)
+			b.TextMessageHandler(ctx, botClient, update)
+			return
+		}
+		handler(ctx, botClient, update)
+	}
+	b.client.RegisterHandler(bot.HandlerTypeMessageText, command, bot.MatchTypeCommandStartOnly, instrument("command", "/"+command, gated), withSender)
+}
+
+// commandArgs returns the text after the command
+func commandArgs(text string) string {
+	_, args, _ := strings.Cut(text, " ")
+	return strings.TrimSpace(args)
+}
+
+func (b *Bot) sendAdminText(ctx context.Context, chatID int64, text string, keyboard [][]models.InlineKeyboardButton) {
+	params := &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if keyboard != nil {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
+	if _, err := b.sendMessage(ctx, params); err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+func (b *Bot) StatsHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	loc := b.loadSettings(ctx, update.Message.From.ID).Location()
+	st, err := b.adminUC.Stats(ctx, time.Now(), loc)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 *Статистика*\n\n")
+	sb.WriteString(fmt.Sprintf("👥 Пользователи: %d \\(активных %d\\)\n", st.Users, st.ActiveUsers))
+	sb.WriteString(fmt.Sprintf("🚆 Поездок создано сегодня: %d\n\n", st.TripsToday))
+	sb.WriteString("🔔 *Напоминания*\n")
+	for _, status := range []domain.ReminderStatus{domain.StatusPending, domain.StatusProcessing, domain.StatusSent, domain.StatusFailed} {
+		sb.WriteString(fmt.Sprintf("   %s: %d\n", escapeMarkdown(string(status)), st.Reminders[string(status)]))
+	}
+	sb.WriteString(fmt.Sprintf("\n🌐 *Yandex API* за %s\n", escapeMarkdown(st.ScheduleWindow.String())))
+	sb.WriteString(fmt.Sprintf("   Запросов: %d, ошибок: %d \\(%s\\)\n",
+		st.ScheduleRequests, st.ScheduleFailures, escapeMarkdown(fmt.Sprintf("%.1f%%", st.ScheduleErrorRate()*100))))
+
+	b.sendAdminText(ctx, update.Message.Chat.ID, sb.String(), nil)
+}
+
+func (b *Bot) UserInfoHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	id, err := strconv.ParseInt(commandArgs(update.Message.Text), 10, 64)
+	if err != nil {
+		b.sendAdminText(ctx, update.Message.Chat.ID, "Использование: /user \\<telegram id или id\\>", nil)
+		return
+	}
+
+	ov, err := b.adminUC.UserOverview(ctx, id)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+	loc := b.loadSettings(ctx, update.Message.From.ID).Location()
+
+	u := ov.User
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👤 *Пользователь \\#%d*\n", u.ID))
+	sb.WriteString(fmt.Sprintf("Telegram ID: `%d`\n", u.TelegramID))
+	sb.WriteString(fmt.Sprintf("Имя: %s\n", escapeMarkdown(u.Name)))
+	if u.Username != "" {
+		sb.WriteString(fmt.Sprintf("Username: @%s\n", escapeMarkdown(u.Username)))
+	}
+	sb.WriteString(fmt.Sprintf("Активен: %s\n", onOff(adminLocalizer, u.IsActive)))
+
+	sb.WriteString(fmt.Sprintf("\n🚆 *Поездки* \\(%d\\)\n", len(ov.Trips)))
+	for _, tr := range lastN(ov.Trips, adminListLimit) {
+		sb.WriteString(fmt.Sprintf("\\#%d %s → %s, %s\n", tr.ID, escapeMarkdown(b.stationLabel(ctx, tr.From, tr.FromName)), escapeMarkdown(b.stationLabel(ctx, tr.To, tr.ToName)),
+			escapeMarkdown(tr.DepartureTime.In(loc).Format("02.01.2006 15:04"))))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n🔔 *Напоминания* \\(%d\\)\n", len(ov.Reminders)))
+	for _, rem := range lastN(ov.Reminders, adminListLimit) {
+		sb.WriteString(fmt.Sprintf("\\#%d %s, %s, попыток %d\n", rem.ID, escapeMarkdown(rem.Status),
+			escapeMarkdown(rem.TriggerAt.In(loc).Format("02.01.2006 15:04")), rem.Attempts))
+	}
+
+	b.sendAdminText(ctx, update.Message.Chat.ID, sb.String(), nil)
+}
+
+func (b *Bot) FailedRemindersHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	failed, err := b.adminUC.FailedReminders(ctx, adminListLimit)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+	if len(failed) == 0 {
+		b.sendAdminText(ctx, update.Message.Chat.ID, "✅ Неотправленных напоминаний нет", nil)
+		return
+	}
+	loc := b.loadSettings(ctx, update.Message.From.ID).Location()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("❌ *Неотправленные напоминания* \\(последние %d\\)\n\n", len(failed)))
+	var keyboard [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+	for _, rem := range failed {
+		sb.WriteString(fmt.Sprintf("\\#%d user %d, %s, попыток %d\n   _%s_\n", rem.ID, rem.UserID,
+			escapeMarkdown(rem.TriggerAt.In(loc).Format("02.01.2006 15:04")), rem.Attempts, escapeMarkdown(truncate(rem.LastError, 120))))
+
+		row = append(row, models.InlineKeyboardButton{Text: fmt.Sprintf("🔁 #%d", rem.ID), CallbackData: fmt.Sprintf("ar:%d", rem.ID)})
+		if len(row) == 4 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard, []models.InlineKeyboardButton{{Text: "🔁 Повторить все", CallbackData: "ar:all"}})
+
+	b.sendAdminText(ctx, update.Message.Chat.ID, sb.String(), keyboard)
+}
+
+// handleAdminRequeue handles "ar:<id>" and "ar:all"
+func (b *Bot) handleAdminRequeue(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	if !b.adminUC.IsAdmin(callbackQuery.From.ID) || len(params) < 1 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "Неизвестная команда")
+		return
+	}
+
+	if params[0] == "all" {
+		n, err := b.adminUC.RequeueFailed(ctx, adminListLimit, time.Now())
+		if err != nil {
+			logging.FromContext(ctx).Error("requeue failed reminders", logging.Err(err))
+			b.answerCallback(ctx, botClient, callbackQuery.ID, errorText(adminLocalizer, err))
+			return
+		}
+		b.answerCallback(ctx, botClient, callbackQuery.ID, fmt.Sprintf("Повторно поставлено в очередь: %d", n))
+		return
+	}
+
+	id, err := strconv.ParseInt(params[0], 10, 64)
+	if err != nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "Неизвестная команда")
+		return
+	}
+	if err := b.adminUC.Requeue(ctx, id, time.Now()); err != nil {
+		if domain.ErrorCode(err) == "" {
+			logging.FromContext(ctx).Error("requeue reminder failed", "reminder_id", id, logging.Err(err))
+		}
+		b.answerCallback(ctx, botClient, callbackQuery.ID, errorText(adminLocalizer, err))
+		return
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, fmt.Sprintf("Напоминание #%d снова в очереди", id))
+}
+
+// BroadcastHandler shows a preview; the announcement is only sent after
+// the admin confirms it
+func (b *Bot) BroadcastHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	text := commandArgs(update.Message.Text)
+	if text == "" {
+		b.sendAdminText(ctx, update.Message.Chat.ID, "Использование: /broadcast \\<текст объявления\\>", nil)
+		return
+	}
+
+	recipients, err := b.adminUC.BroadcastRecipients(ctx)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	session := b.getSession(update.Message.From.ID)
+	session.PendingBroadcast = text
+
+	preview := fmt.Sprintf("📣 *Рассылка* для %d пользователей:\n\n%s", len(recipients), escapeMarkdown(text))
+	b.sendAdminText(ctx, update.Message.Chat.ID, preview, [][]models.InlineKeyboardButton{{
+		{Text: "✅ Отправить", CallbackData: "ab:send"},
+		{Text: "✖️ Отмена", CallbackData: "ab:cancel"},
+	}})
+}
+
+// handleAdminBroadcast handles "ab:send" and "ab:cancel"
+func (b *Bot) handleAdminBroadcast(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, params []string) {
+	if !b.adminUC.IsAdmin(callbackQuery.From.ID) || len(params) < 1 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "Неизвестная команда")
+		return
+	}
+	text := session.PendingBroadcast
+	session.PendingBroadcast = ""
+
+	if params[0] != "send" || text == "" {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "Рассылка отменена")
+		return
+	}
+	if !b.broadcasting.CompareAndSwap(false, true) {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "Другая рассылка еще идет")
+		return
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "Рассылка запущена")
+
+	// The handler context lives as long as the bot, so the broadcast stops
+	// on shutdown
+	go func() {
+		defer b.broadcasting.Store(false)
+		sent, blocked, failed, err := b.broadcast(ctx, text)
+		report := fmt.Sprintf("📣 Рассылка завершена: отправлено %d, заблокировали бота %d, ошибок %d", sent, blocked, failed)
+		if err != nil {
+			report = fmt.Sprintf("📣 Рассылка прервана: %v\nОтправлено %d, заблокировали бота %d, ошибок %d", err, sent, blocked, failed)
+		}
+		if err := b.SendMessage(context.WithoutCancel(ctx), callbackQuery.From.ID, report); err != nil {
+			logging.FromContext(ctx).Error("send broadcast report failed", logging.Err(err))
+		}
+	}()
+}
+
+func (b *Bot) broadcast(ctx context.Context, text string) (sent, blocked, failed int, err error) {
+	log := logging.FromContext(ctx)
+	recipients, err := b.adminUC.BroadcastRecipients(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	log.Info("broadcast started", "recipients", len(recipients))
+
+	// The dispatcher paces the lane and retries on 429, so reminders and
+	// replies to users are never stuck behind a long broadcast
+	for _, u := range recipients {
+		if ctx.Err() != nil {
+			return sent, blocked, failed, ctx.Err()
+		}
+
+		_, err := b.sendMessageWith(ctx, PriorityBroadcast, &bot.SendMessageParams{
+			ChatID: u.ChatID,
+			Text:   text,
+		})
+		switch {
+		case err == nil:
+			sent++
+		case IsBlockedByUser(err):
+			blocked++
+			if err := b.userUC.Deactivate(ctx, u.ID); err != nil {
+				log.Error("deactivate user failed", "user_id", u.ID, logging.Err(err))
+			}
+		default:
+			failed++
+			log.Warn("broadcast message failed", "user_id", u.ID, logging.Err(err))
+		}
+	}
+	log.Info("broadcast finished", "sent", sent, "blocked", blocked, "failed", failed)
+	return sent, blocked, failed, nil
+}
+
+func lastN[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[len(items)-n:]
+	}
+	return items
+}
+
+// truncate keeps messages under Telegram's 4096 character limit
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
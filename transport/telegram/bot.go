@@ -2,16 +2,21 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/metrics"
 	"github.com/X1ag/TravelScheduler/internal/usecase"
 	"github.com/X1ag/TravelScheduler/internal/utils"
+	"github.com/X1ag/TravelScheduler/transport/telegram/render"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
@@ -23,12 +28,18 @@ const (
 	StateSelectingFrom   UserState = "selecting_from"     // Inline station buttons
 	StateSelectingTo     UserState = "selecting_to"       // Inline station buttons
 	StateShowingSchedule UserState = "showing_schedule"   // Paginated results
+	StateWaitingBookImport UserState = "waiting_book_import" // Expecting a CSV document
+	StateWaitingHome       UserState = "waiting_home"        // Typed home station for /settings
+	StateWaitingWork       UserState = "waiting_work"        // Typed work station for /settings
+	StateSelectingHome     UserState = "selecting_home"      // Typed-input matches for the home station
+	StateSelectingWork     UserState = "selecting_work"      // Typed-input matches for the work station
 	// Legacy states for backward compatibility during migration
 	StateWaitingFrom UserState = "waiting_from"
 	StateWaitingTo   UserState = "waiting_to"
 )
 
 type UserSession struct {
+	TelegramID   int64
 	State        UserState
 	StateHistory []UserState // For back navigation
 
@@ -42,6 +53,12 @@ type UserSession struct {
 	SchedulePage   int              // Current page for pagination
 	RecentStations []utils.StationOption  // Last 5 used stations
 	LastMessageID  int              // For editing messages
+	LastActivity   time.Time        // Idle sessions are evicted after sessionTTL
+
+	StationMatches []utils.StationOption // Typed-input matches offered as ss:m buttons
+
+	PendingBroadcast string // Admin announcement waiting for confirmation
+	EditTripID       int64  // Trip whose train is being changed, 0 when booking a new one
 }
 
 type Bot struct {
@@ -49,17 +66,37 @@ type Bot struct {
 	tripUC      *usecase.TripUsecase
 	bookUC      *usecase.BookUsecase
 	userUC      *usecase.UserUsecase
+	settingsUC  *usecase.SettingsUsecase
+	reminderUC  *usecase.ReminderUsecase
+	adminUC     *usecase.AdminUsecase
+	calendarUC  *usecase.CalendarUsecase
+	dataUC      *usecase.DataExportUsecase
+	dispatcher  *Dispatcher
 	userSessions map[int64]*UserSession // telegramID -> session
+	groupButtons map[groupMessage]buttonOwner
+	sessionTTL  time.Duration
 	mu          sync.RWMutex
+
+	pollingRunning    atomic.Bool // set while Start fetches updates
+	webhookRunning    atomic.Bool // set while StartWebhook processes updates
+	webhookRegistered atomic.Bool // set between SetWebhook and DeleteWebhook
+	broadcasting      atomic.Bool // an admin broadcast is being sent
 }
 
-func NewBot(client *bot.Bot, tripUC *usecase.TripUsecase, bookUC *usecase.BookUsecase, userUC *usecase.UserUsecase) *Bot {
+func NewBot(client *bot.Bot, tripUC *usecase.TripUsecase, bookUC *usecase.BookUsecase, userUC *usecase.UserUsecase, settingsUC *usecase.SettingsUsecase, reminderUC *usecase.ReminderUsecase, adminUC *usecase.AdminUsecase, calendarUC *usecase.CalendarUsecase, dataUC *usecase.DataExportUsecase) *Bot {
 	return &Bot{
 		client:       client,
 		tripUC:       tripUC,
 		bookUC:       bookUC,
 		userUC:       userUC,
+		settingsUC:   settingsUC,
+		reminderUC:   reminderUC,
+		adminUC:      adminUC,
+		calendarUC:   calendarUC,
+		dataUC:       dataUC,
+		dispatcher:   NewDispatcher(DefaultDispatcherConfig()),
 		userSessions: make(map[int64]*UserSession),
+		groupButtons: make(map[groupMessage]buttonOwner),
 	}
 }
 
@@ -68,12 +105,15 @@ func (b *Bot) getSession(telegramID int64) *UserSession {
 	defer b.mu.Unlock()
 	
 	if session, exists := b.userSessions[telegramID]; exists {
+		session.LastActivity = time.Now()
 		return session
 	}
 	
 	session := &UserSession{
-		State: StateNone,
-		Date:  time.Now(),
+		TelegramID:   telegramID,
+		State:        StateNone,
+		Date:         time.Now(),
+		LastActivity: time.Now(),
 	}
 	b.userSessions[telegramID] = session
 	return session
@@ -121,82 +161,136 @@ func (b *Bot) addToRecentStations(session *UserSession, station utils.StationOpt
 	}
 }
 
-func (b *Bot) ensureUser(ctx context.Context, telegramID int64, firstName, username string) (*domain.User, error) {
-	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+// ensureUser returns the sender's account, registering them on first contact
+// with the language of their Telegram client. The private chat is remembered
+// the first time the user writes to the bot directly.
+func (b *Bot) ensureUser(ctx context.Context, from *models.User, chat models.Chat) (*domain.User, error) {
+	var privateChat int64
+	if chat.Type == models.ChatTypePrivate {
+		privateChat = chat.ID
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, from.ID)
 	if err == nil {
+		if !user.IsActive && privateChat != 0 {
+			// User blocked the bot earlier and came back to the private chat
+			if err := b.userUC.Activate(ctx, user.ID); err != nil {
+				return nil, err
+			}
+			user.IsActive = true
+		}
+		if user.ChatID == 0 && privateChat != 0 {
+			if err := b.userUC.SetChatID(ctx, user.ID, privateChat); err != nil {
+				return nil, err
+			}
+			user.ChatID = privateChat
+		}
 		return user, nil
 	}
 	
 	newUser := &domain.User{
-		TelegramID: telegramID,
-		Name:       firstName,
-		Username:   username,
+		TelegramID: from.ID,
+		Name:       from.FirstName,
+		Username:   from.Username,
+		ChatID:     privateChat,
 	}
 	
 	err = b.userUC.Create(ctx, newUser)
 	if err != nil {
 		return nil, err
 	}
+
+	if lang := i18n.Match(from.LanguageCode); lang != "" && lang != i18n.Default {
+		_, err := b.settingsUC.Update(ctx, newUser.ID, func(st *domain.UserSettings) { st.Language = lang })
+		if err != nil {
+			logging.FromContext(ctx).Warn("store user language failed", logging.Err(err))
+		}
+	}
 	
 	return newUser, nil
 }
 
 func (b *Bot) Start(ctx context.Context) {
+	if b.sessionTTL > 0 {
+		go b.evictIdleSessions(ctx)
+	}
+	b.pollingRunning.Store(true)
+	defer b.pollingRunning.Store(false)
 	b.client.Start(ctx)
 }
 
+// Receiving reports whether updates are being received: either polling
+// runs, or the webhook is registered and its updates are processed
+func (b *Bot) Receiving() (mode string, ok bool) {
+	if b.pollingRunning.Load() {
+		return "polling", true
+	}
+	if b.webhookRegistered.Load() {
+		return "webhook", b.webhookRunning.Load()
+	}
+	return "", false
+}
+
+// SetSessionTTL sets how long an idle dialog session is kept in memory
+func (b *Bot) SetSessionTTL(ttl time.Duration) {
+	b.sessionTTL = ttl
+}
+
+func (b *Bot) evictIdleSessions(ctx context.Context) {
+	ticker := time.NewTicker(b.sessionTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			for telegramID, session := range b.userSessions {
+				if now.Sub(session.LastActivity) > b.sessionTTL {
+					delete(b.userSessions, telegramID)
+				}
+			}
+			b.evictGroupButtons(now)
+			b.mu.Unlock()
+		}
+	}
+}
+
 func (b *Bot) AddClient(botClient *bot.Bot) {
 	b.client = botClient
 }
 
 func (b *Bot) StartHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
-	telegramID := update.Message.From.ID
-	
-	_, err := b.ensureUser(ctx, telegramID, update.Message.From.FirstName, update.Message.From.Username)
+	_, err := b.ensureUser(ctx, update.Message.From, update.Message.Chat)
 	if err != nil {
-		log.Printf("Ошибка регистрации пользователя: %v", err)
-		sendErrorMessage(err, ctx, botClient, update)
+		logging.FromContext(ctx).Error("register user failed", logging.Err(err))
+		b.sendErrorMessage(ctx, update, err)
 		return
 	}
 	
-	welcomeText := "👋 *Добро пожаловать в TravelPet\\!*\n\n" +
-		"Я помогу вам планировать поездки и напомню о них заранее\\.\n\n" +
-		"*Доступные команды:*\n" +
-		"/newtrip — создать новую поездку\n" +
-		"/mytrips — мои поездки\n" +
-		"/help — справка\n\n" +
-		"Начнем планировать поездку? Нажмите /newtrip"
-
-	_, err = botClient.SendMessage(ctx, &bot.SendMessageParams{
+	msg := renderScreen(ctx, b.localizer(ctx, update.Message.From), render.Welcome, nil)
+
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    update.Message.Chat.ID,
-		Text:      welcomeText,
-		ParseMode: models.ParseModeMarkdown,
+		Text:      msg.Text,
+		ParseMode: msg.ParseMode,
 	})
 	if err != nil {
-		log.Println(err)
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 	}
 }
 
 func (b *Bot) HelpHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
-	helpText := "📖 *Справка по командам*\n\n" +
-		"/newtrip — создать новую поездку\n" +
-		"   Бот проведет вас через пошаговый процесс создания поездки\n\n" +
-		"/mytrips — показать все ваши запланированные поездки\n\n" +
-		"/help — показать эту справку\n\n" +
-		"*Как создать поездку:*\n" +
-		"1\\. Нажмите /newtrip\n" +
-		"2\\. Введите станцию отправления \\(например: s9613483 или Таганрог\\)\n" +
-		"3\\. Введите станцию назначения\n" +
-		"4\\. Выберите поезд из предложенного расписания\n" +
-		"5\\. Готово\\! Бот напомнит вам за 30 минут до отправления"
-
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	msg := renderScreen(ctx, b.localizer(ctx, update.Message.From), render.Help, nil)
+
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    update.Message.Chat.ID,
-		Text:      helpText,
-		ParseMode: models.ParseModeMarkdown,
+		Text:      msg.Text,
+		ParseMode: msg.ParseMode,
 	})
 	if err != nil {
-		log.Println(err)
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 	}
 }
 
@@ -204,9 +298,9 @@ func (b *Bot) NewTripHandler(ctx context.Context, botClient *bot.Bot, update *mo
 	telegramID := update.Message.From.ID
 
 	if b.userSessions[telegramID] == nil {
-		_, err := b.ensureUser(ctx, telegramID, update.Message.From.FirstName, update.Message.From.Username)
+		_, err := b.ensureUser(ctx, update.Message.From, update.Message.Chat)
 		if err != nil {
-			log.Printf("Ошибка регистрации пользователя: %v", err)
+			logging.FromContext(ctx).Error("register user failed", logging.Err(err))
 		}
 	}
 
@@ -221,78 +315,25 @@ func (b *Bot) NewTripHandler(ctx context.Context, botClient *bot.Bot, update *mo
 	session.Date = time.Now()
 	session.Schedule = nil
 	session.SchedulePage = 0
+	session.EditTripID = 0 // an abandoned ✏️ change must not catch this booking
 
 	// Show inline station selection
 	b.showStationSelection(ctx, botClient, update.Message.Chat.ID, session, "from")
 }
 
-func (b *Bot) MyTripsHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
-	telegramID := update.Message.From.ID
-	
-	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
-	if err != nil {
-		sendErrorMessage(err, ctx, botClient, update)
-		return
-	}
-	
-	trips, err := b.tripUC.GetByUserID(ctx, user.ID)
-	if err != nil {
-		sendErrorMessage(err, ctx, botClient, update)
-		return
-	}
-	
-	if len(trips) == 0 {
-		text := "📋 *Мои поездки*\n\n" +
-			"У вас пока нет запланированных поездок\\.\n\n" +
-			"Создайте новую поездку командой /newtrip"
-
-		_, err = botClient.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:    update.Message.Chat.ID,
-			Text:      text,
-			ParseMode: models.ParseModeMarkdown,
-		})
-		if err != nil {
-			log.Println(err)
-		}
-		return
-	}
-	
-	var sb strings.Builder
-	sb.WriteString("📋 *Мои поездки*\n\n")
-	
-	for i, trip := range trips {
-		depTime := trip.DepartureTime.Format("02.01.2006 15:04")
-		escapedFrom := escapeMarkdown(trip.From)
-		escapedTo := escapeMarkdown(trip.To)
-		sb.WriteString(fmt.Sprintf("*%d\\.* 🚆 Поездка #%d\n", i+1, trip.ID))
-		sb.WriteString(fmt.Sprintf("   📍 %s → %s\n", escapedFrom, escapedTo))
-		sb.WriteString(fmt.Sprintf("   🕒 %s\n\n", depTime))
-	}
-	
-	_, err = botClient.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:    update.Message.Chat.ID,
-		Text:      sb.String(),
-		ParseMode: models.ParseModeMarkdown,
-	})
-	if err != nil {
-		log.Println(err)
-	}
-}
-
 func (b *Bot) CancelHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
 	telegramID := update.Message.From.ID
 	b.clearSession(telegramID)
 	
-	text := "❌ *Создание поездки отменено*\n\n" +
-		"Вы можете начать заново командой /newtrip"
+	text := b.localizer(ctx, update.Message.From).T("trip.cancelled")
 
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    update.Message.Chat.ID,
 		Text:      text,
 		ParseMode: models.ParseModeMarkdown,
 	})
 	if err != nil {
-		log.Println(err)
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 	}
 }
 
@@ -300,6 +341,7 @@ func (b *Bot) TextMessageHandler(ctx context.Context, botClient *bot.Bot, update
 	telegramID := update.Message.From.ID
 	session := b.getSession(telegramID)
 	text := strings.TrimSpace(update.Message.Text)
+	l := b.localizer(ctx, update.Message.From)
 	
 	if text == "/cancel" || text == "/cancel_" {
 		b.CancelHandler(ctx, botClient, update)
@@ -308,73 +350,73 @@ func (b *Bot) TextMessageHandler(ctx context.Context, botClient *bot.Bot, update
 	
 	switch session.State {
 	case StateWaitingFrom:
-		// Text input for "From" station
-		session.From = text
-		session.FromName = text // Use text as display name for now
-		b.transitionState(session, StateWaitingTo)
-
-		// Try to add to recent if it's a known station
-		if station, found := utils.GetStationByCode(text); found {
-			session.FromName = station.DisplayName
-			b.addToRecentStations(session, station)
+		// Text input for "From" station; a new route is a new booking
+		session.EditTripID = 0
+		station, ok := b.resolveTypedStation(ctx, update.Message.Chat.ID, session, l, text, StateSelectingFrom, true)
+		if !ok {
+			return
 		}
+		session.From = station.Code
+		session.FromName = station.DisplayName
+		b.transitionState(session, StateWaitingTo)
 
 		escapedText := escapeMarkdown(session.FromName)
-		msgText := fmt.Sprintf("✅ Станция отправления: *%s*\n\n"+
-			"Шаг 2 из 3: *Введите станцию назначения*\n\n"+
-			"Вы можете ввести:\n"+
-			"• Код станции \\(например: s9612913\\)\n"+
-			"• Название станции \\(например: Ростов\\-на\\-Дону\\)\n\n"+
-			"_Для отмены введите /cancel_", escapedText)
-
-		_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+		msgText := l.T("trip.step_to", escapedText)
+
+		_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 			ChatID:    update.Message.Chat.ID,
 			Text:      msgText,
 			ParseMode: models.ParseModeMarkdown,
 		})
 		if err != nil {
-			log.Println(err)
+			logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 		}
 
 	case StateWaitingTo:
 		// Text input for "To" station
-		session.To = text
-		session.ToName = text // Use text as display name for now
-		b.transitionState(session, StateShowingSchedule)
-
-		// Try to add to recent if it's a known station
-		if station, found := utils.GetStationByCode(text); found {
-			session.ToName = station.DisplayName
-			b.addToRecentStations(session, station)
+		session.EditTripID = 0
+		station, ok := b.resolveTypedStation(ctx, update.Message.Chat.ID, session, l, text, StateSelectingTo, true)
+		if !ok {
+			return
 		}
+		session.To = station.Code
+		session.ToName = station.DisplayName
+		b.transitionState(session, StateShowingSchedule)
 
 		options, err := b.tripUC.Search(ctx, session.From, session.To, session.Date)
 		if err != nil {
 			session.State = StateWaitingTo
-			sendErrorMessage(err, ctx, botClient, update)
+			b.sendErrorMessage(ctx, update, err)
 			return
 		}
 
 		if len(options) == 0 {
 			session.State = StateWaitingTo
-			b.sendRecoverableError(ctx, botClient, update.Message.Chat.ID,
-				"Рейсы не найдены для этого маршрута.",
+			b.sendRecoverableError(ctx, botClient, update.Message.Chat.ID, l,
+				l.T("trip.no_trains"),
 				[]models.InlineKeyboardButton{
-					{Text: "🔄 Попробовать снова", CallbackData: "ef"},
-					{Text: "❌ Отменить", CallbackData: "x"},
+					{Text: l.T("button.retry"), CallbackData: "ef"},
+					{Text: l.T("button.cancel"), CallbackData: "x"},
 				})
 			return
 		}
 
 		b.sendScheduleWithButtons(ctx, botClient, update, options, session)
+
+	case StateWaitingHome, StateWaitingWork:
+		b.handleStationSettingInput(ctx, update, session, l, text)
 		
 	default:
-		_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+		if isGroupChat(update.Message.Chat) {
+			// Messages in a group are mostly meant for other members
+			return
+		}
+		_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "Для начала создания поездки используйте команду /newtrip\nДля справки: /help",
+			Text:   l.T("text.fallback"),
 		})
 		if err != nil {
-			log.Println(err)
+			logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 		}
 	}
 }
@@ -390,21 +432,23 @@ func (b *Bot) sendScheduleWithButtons(ctx context.Context, botClient *bot.Bot, u
 		toDisplay = session.To
 	}
 
-	text := buildScheduleText(options, fromDisplay, toDisplay)
+	settings := b.loadSettings(ctx, session.TelegramID)
+	l := i18n.For(settings.Language)
+	msg := renderScreen(ctx, l, render.Schedule, buildScheduleData(l, options, fromDisplay, toDisplay, settings.ShowDetails, settings.Location()))
 	session.Schedule = options
 	session.SchedulePage = 0
 
 	// Use new pagination keyboard
-	keyboard := b.buildScheduleKeyboard(options, session.SchedulePage)
+	keyboard := b.buildScheduleKeyboard(l, options, session.SchedulePage, settings.Location(), session.EditTripID)
 
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
-		Text:        text,
-		// No ParseMode - avoid markdown escaping issues
+		Text:        msg.Text,
+		ParseMode:   msg.ParseMode,
 		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
 	})
 	if err != nil {
-		log.Println(err)
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
 	}
 }
 
@@ -414,11 +458,18 @@ func (b *Bot) CallbackQueryHandler(ctx context.Context, botClient *bot.Bot, upda
 		return
 	}
 
+	action, params := ParseCallback(callbackQuery.Data)
+	start := time.Now()
+	defer func() { metrics.ObserveUpdate("callback", action, time.Since(start)) }()
+
+	if !b.mayPress(callbackQuery, action) {
+		sendCallbackError(ctx, botClient, callbackQuery, b.localizer(ctx, &callbackQuery.From).T("group.foreign_buttons"))
+		return
+	}
+
 	telegramID := callbackQuery.From.ID
 	session := b.getSession(telegramID)
 
-	action, params := ParseCallback(callbackQuery.Data)
-
 	// Route to appropriate handler
 	switch action {
 	case "b": // Back
@@ -437,6 +488,7 @@ func (b *Bot) CallbackQueryHandler(ctx context.Context, botClient *bot.Bot, upda
 		b.handleSchedulePage(ctx, botClient, callbackQuery, session, params)
 
 	case "ef": // Edit From
+		session.EditTripID = 0 // picking stations starts a new booking
 		session.State = StateSelectingFrom
 		b.transitionState(session, StateSelectingFrom)
 		if callbackQuery.Message.Message != nil {
@@ -445,6 +497,7 @@ func (b *Bot) CallbackQueryHandler(ctx context.Context, botClient *bot.Bot, upda
 		b.answerCallback(ctx, botClient, callbackQuery.ID, "")
 
 	case "et": // Edit To
+		session.EditTripID = 0
 		session.State = StateSelectingTo
 		b.transitionState(session, StateSelectingTo)
 		if callbackQuery.Message.Message != nil {
@@ -458,18 +511,80 @@ func (b *Bot) CallbackQueryHandler(ctx context.Context, botClient *bot.Bot, upda
 	case "noop": // No operation (pagination indicator)
 		b.answerCallback(ctx, botClient, callbackQuery.ID, "")
 
+	case "st": // Settings menu
+		b.handleSettingsMenu(ctx, botClient, callbackQuery, params)
+
+	case "sv": // Settings value
+		b.handleSettingsValue(ctx, botClient, callbackQuery, params)
+
+	case "si": // Settings station typed input
+		b.handleStationSettingStart(ctx, botClient, callbackQuery, session, params)
+
+	case "rs": // Reminder snooze
+		b.handleReminderSnooze(ctx, botClient, callbackQuery, params)
+
+	case "ra": // Reminder acknowledge
+		b.handleReminderAck(ctx, botClient, callbackQuery, params)
+
+	case "ab": // Admin broadcast confirmation
+		b.handleAdminBroadcast(ctx, botClient, callbackQuery, session, params)
+
+	case "te": // Trip edit: pick a date
+		b.handleTripEdit(ctx, botClient, callbackQuery, params)
+
+	case "td": // Trip edit: date picked, show trains
+		b.handleTripEditDate(ctx, botClient, callbackQuery, params)
+
+	case "tj": // Trip join from a group chat
+		b.handleTripJoin(ctx, botClient, callbackQuery, params)
+
+	case "tv": // Trip view
+		b.handleTripView(ctx, botClient, callbackQuery, params)
+
+	case "th": // Trip history page
+		b.handleTripHistory(ctx, botClient, callbackQuery, params)
+
+	case "ar": // Admin requeue failed reminder
+		b.handleAdminRequeue(ctx, botClient, callbackQuery, params)
+
+	case "cl": // Calendar subscription link
+		b.handleCalendarLink(ctx, botClient, callbackQuery)
+
+	case "cr": // Calendar link revoke
+		b.handleCalendarRevoke(ctx, botClient, callbackQuery)
+
+	case "dx": // Data export
+		b.handleDataExport(ctx, botClient, callbackQuery, params)
+
+	case "bi": // Book import: wait for a file
+		b.handleBookImportStart(ctx, botClient, callbackQuery, session)
+
+	case "dd": // Delete account confirmed
+		b.handleDeleteConfirm(ctx, botClient, callbackQuery, params)
+
+	case "dn": // Delete account cancelled
+		b.handleDeleteCancel(ctx, botClient, callbackQuery)
+
 	default:
 		// Legacy support for old callback format
 		if strings.HasPrefix(callbackQuery.Data, "train:") || callbackQuery.Data == "cancel" {
+			action = "legacy"
 			b.handleLegacyCallback(ctx, botClient, callbackQuery, session)
 		} else {
-			b.answerCallback(ctx, botClient, callbackQuery.ID, "Неизвестная команда")
+			// Callback data comes from the client, keep it out of metric labels
+			action = "unknown"
+			b.answerCallback(ctx, botClient, callbackQuery.ID, b.localizer(ctx, &callbackQuery.From).T("common.unknown_command"))
 		}
 	}
 }
 
 // handleCancel handles cancel action
 func (b *Bot) handleCancel(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	text := l.T("trip.cancelled")
+	if session.EditTripID != 0 {
+		text = l.T("trip.edit_cancelled")
+	}
 	b.clearSession(callbackQuery.From.ID)
 
 	var chatID int64
@@ -478,14 +593,14 @@ func (b *Bot) handleCancel(ctx context.Context, botClient *bot.Bot, callbackQuer
 		msg := callbackQuery.Message.Message
 		chatID = msg.Chat.ID
 		messageID = msg.ID
-		_, err := botClient.EditMessageText(ctx, &bot.EditMessageTextParams{
+		_, err := b.editMessageText(ctx, &bot.EditMessageTextParams{
 			ChatID:    chatID,
 			MessageID: messageID,
-			Text:      "❌ *Создание поездки отменено*\n\nВы можете начать заново командой /newtrip",
+			Text:      text,
 			ParseMode: models.ParseModeMarkdown,
 		})
 		if err == nil {
-			b.answerCallback(ctx, botClient, callbackQuery.ID, "Отменено")
+			b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.cancelled"))
 			return
 		}
 	}
@@ -493,82 +608,104 @@ func (b *Bot) handleCancel(ctx context.Context, botClient *bot.Bot, callbackQuer
 	if chatID == 0 {
 		chatID = callbackQuery.From.ID
 	}
-	_, _ = botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, _ = b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatID,
-		Text:      "❌ *Создание поездки отменено*\n\nВы можете начать заново командой /newtrip",
+		Text:      text,
 		ParseMode: models.ParseModeMarkdown,
 	})
-	b.answerCallback(ctx, botClient, callbackQuery.ID, "Отменено")
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.cancelled"))
 }
 
 // handleTrainSelect handles train selection and trip confirmation
 func (b *Bot) handleTrainSelect(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
 	if len(params) == 0 {
-		sendCallbackError(ctx, botClient, callbackQuery, "Ошибка: неверные параметры")
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
 		return
 	}
 
 	index, err := strconv.Atoi(params[0])
 	if err != nil {
-		sendCallbackError(ctx, botClient, callbackQuery, "Ошибка формата")
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_format"))
 		return
 	}
 
 	if session.Schedule == nil || index < 0 || index >= len(session.Schedule) {
-		sendCallbackError(ctx, botClient, callbackQuery, "Ошибка: расписание не найдено")
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("trip.schedule_missing"))
 		return
 	}
 
 	opt := session.Schedule[index]
+	if session.EditTripID != 0 {
+		b.handleTripChange(ctx, botClient, callbackQuery, session, opt)
+		return
+	}
 
 	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
 	if err != nil {
-		sendCallbackError(ctx, botClient, callbackQuery, "Ошибка получения данных пользователя")
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
 		return
 	}
 
 	tr := &domain.Trip{
-		UserID:        user.ID,
-		From:          session.From,
-		To:            session.To,
-		DepartureTime: opt.DepartureTime,
+		UserID:   user.ID,
+		From:     session.From,
+		To:       session.To,
+		FromName: session.FromName,
+		ToName:   session.ToName,
+	}
+	if msg := callbackQuery.Message.Message; msg != nil && isGroupChat(msg.Chat) {
+		tr.ChatID = msg.Chat.ID
 	}
+	tr.ApplySchedule(opt)
 
-	err = b.tripUC.ConfirmTrip(ctx, tr)
-	if err != nil {
-		sendCallbackError(ctx, botClient, callbackQuery, fmt.Sprintf("Ошибка: %s", err.Error()))
+	err = b.tripUC.ConfirmTrip(ctx, tr, callbackQuery.ID)
+	alreadyBooked := errors.Is(err, domain.ErrTripAlreadyBooked)
+	if err != nil && !alreadyBooked {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
 		return
 	}
 
 	b.clearSession(callbackQuery.From.ID)
 
-	depTime := opt.DepartureTime.Format("02.01.2006 15:04")
-	escapedTrainID := escapeMarkdown(opt.TrainID)
-	escapedFrom := escapeMarkdown(session.FromName)
-	escapedTo := escapeMarkdown(session.ToName)
+	settings := b.loadSettings(ctx, callbackQuery.From.ID)
 
-	successText := fmt.Sprintf("✅ *Поездка успешно создана\\!*\n\n"+
-		"📋 *Детали поездки:*\n"+
-		"🚆 Поезд: *%s*\n"+
-		"📍 Маршрут: *%s* → *%s*\n"+
-		"🕒 Отправление: *%s*\n\n"+
-		"Я напомню вам за 30 минут до отправления\\. Приятной поездки\\! 🚂",
-		escapedTrainID, escapedFrom, escapedTo, depTime)
+	msg := renderScreen(ctx, l, render.TripConfirmed, render.TripConfirmedData{
+		Details:       tripDetails(l, tr, tr.FromDisplay(), tr.ToDisplay(), settings.Location(), ""),
+		LeadMinutes:   settings.ReminderLeadMinutes,
+		AlreadyBooked: alreadyBooked,
+	})
+	answer := l.T("trip.created_answer")
+	var buttons [][]models.InlineKeyboardButton
+	if alreadyBooked {
+		answer = errorText(l, domain.ErrTripAlreadyBooked)
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: l.T("button.open_trip", tr.ID), CallbackData: fmt.Sprintf("tv:%d", tr.ID)},
+		})
+	}
+	if tr.ChatID != 0 {
+		// Other members of the group can join the trip
+		buttons = append(buttons, []models.InlineKeyboardButton{joinTripButton(l, tr.ID)})
+	}
+	var markup models.ReplyMarkup
+	if len(buttons) > 0 {
+		markup = &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+	}
 
 	var chatID int64
 	var messageID int
-	if callbackQuery.Message.Message != nil {
-		msg := callbackQuery.Message.Message
-		chatID = msg.Chat.ID
-		messageID = msg.ID
-		_, err = botClient.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:    chatID,
-			MessageID: messageID,
-			Text:      successText,
-			ParseMode: models.ParseModeMarkdown,
+	if prev := callbackQuery.Message.Message; prev != nil {
+		chatID = prev.Chat.ID
+		messageID = prev.ID
+		_, err = b.editMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        msg.Text,
+			ParseMode:   msg.ParseMode,
+			ReplyMarkup: markup,
 		})
 		if err == nil {
-			b.answerCallback(ctx, botClient, callbackQuery.ID, "Поездка создана!")
+			b.answerCallback(ctx, botClient, callbackQuery.ID, answer)
 			return
 		}
 	}
@@ -576,27 +713,35 @@ func (b *Bot) handleTrainSelect(ctx context.Context, botClient *bot.Bot, callbac
 	if chatID == 0 {
 		chatID = callbackQuery.From.ID
 	}
-	_, _ = botClient.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:    chatID,
-		Text:      successText,
-		ParseMode: models.ParseModeMarkdown,
+	_, _ = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        msg.Text,
+		ParseMode:   msg.ParseMode,
+		ReplyMarkup: markup,
 	})
-	b.answerCallback(ctx, botClient, callbackQuery.ID, "Поездка создана!")
+	b.answerCallback(ctx, botClient, callbackQuery.ID, answer)
 }
 
 // handleTextInputFallback switches to text input mode
 func (b *Bot) handleTextInputFallback(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession) {
+	l := b.localizer(ctx, &callbackQuery.From)
 	var text string
 	var newState UserState
 
 	if session.State == StateSelectingFrom {
-		text = "⌨️ *Введите название или код станции отправления*\n\nНапример: Таганрог или s9613483"
+		text = l.T("trip.input_from")
 		newState = StateWaitingFrom
 	} else if session.State == StateSelectingTo {
-		text = "⌨️ *Введите название или код станции назначения*\n\nНапример: Ростов-на-Дону или s9612913"
+		text = l.T("trip.input_to")
 		newState = StateWaitingTo
+	} else if session.State == StateSelectingHome {
+		text = l.T("settings.input_home")
+		newState = StateWaitingHome
+	} else if session.State == StateSelectingWork {
+		text = l.T("settings.input_work")
+		newState = StateWaitingWork
 	} else {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Ошибка состояния")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.state_error"))
 		return
 	}
 
@@ -610,7 +755,7 @@ func (b *Bot) handleTextInputFallback(ctx context.Context, botClient *bot.Bot, c
 		chatID = callbackQuery.From.ID
 	}
 
-	_, _ = botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, _ = b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatID,
 		Text:      text,
 		ParseMode: models.ParseModeMarkdown,
@@ -644,16 +789,94 @@ func (b *Bot) answerCallback(ctx context.Context, botClient *bot.Bot, callbackID
 	})
 }
 
+// stationMatchLimit is how many stations typed input offers to pick from
+const stationMatchLimit = 8
+
+// resolveTypedStation turns typed input into a station. A known code, or the
+// only station with exactly that name, is taken as is. Several matches are
+// shown as buttons, the session moves to selecting, and ok is false. Input
+// matching nothing is kept as a code for the schedule API if keepUnknown,
+// otherwise the user is asked to type another name and ok is false.
+func (b *Bot) resolveTypedStation(ctx context.Context, chatID int64, session *UserSession, l *i18n.Localizer, text string, selecting UserState, keepUnknown bool) (utils.StationOption, bool) {
+	if station, found := b.tripUC.FindStation(ctx, text); found {
+		b.addToRecentStations(session, station)
+		return station, true
+	}
+
+	matches, err := b.tripUC.SearchStations(ctx, text, stationMatchLimit)
+	if err != nil {
+		logging.FromContext(ctx).Warn("search stations failed", logging.Err(err))
+	}
+	switch {
+	case len(matches) == 0 && keepUnknown:
+		return utils.StationOption{Code: text, DisplayName: text}, true
+	case len(matches) == 0:
+		_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      l.T("trip.station_no_match", escapeMarkdown(text)),
+			ParseMode: models.ParseModeMarkdown,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+		}
+		return utils.StationOption{}, false
+	case len(matches) == 1 && strings.EqualFold(matches[0].DisplayName, text):
+		b.addToRecentStations(session, matches[0])
+		return matches[0], true
+	}
+
+	session.StationMatches = matches
+	session.State = selecting
+	keyboard := make([][]models.InlineKeyboardButton, 0, len(matches)+1)
+	for i, station := range matches {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: "📍 " + station.DisplayName, CallbackData: fmt.Sprintf("ss:m%d", i)},
+		})
+	}
+	keyboard = append(keyboard, []models.InlineKeyboardButton{
+		{Text: l.T("button.type_station"), CallbackData: "text_input"},
+		{Text: l.T("button.cancel"), CallbackData: "x"},
+	})
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        l.T("trip.pick_station", escapeMarkdown(text)),
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+	return utils.StationOption{}, false
+}
+
 // showStationSelection displays inline keyboard with recent and popular stations
 func (b *Bot) showStationSelection(ctx context.Context, botClient *bot.Bot, chatID int64, session *UserSession, mode string) {
-	var text string
+	// Home and work stations come from /settings
+	settings := b.loadSettings(ctx, session.TelegramID)
+	l := i18n.For(settings.Language)
+
+	text := l.T("trip.select_to")
 	if mode == "from" {
-		text = "📍 Выберите станцию отправления\n\nВыберите из недавних или популярных:"
-	} else {
-		text = "📍 Выберите станцию назначения\n\nВыберите из недавних или популярных:"
+		text = l.T("trip.select_from")
 	}
 
 	buttons := [][]models.InlineKeyboardButton{}
+	favRow := []models.InlineKeyboardButton{}
+	if settings.HomeStation != nil {
+		favRow = append(favRow, models.InlineKeyboardButton{
+			Text:         "🏠 " + b.stationSettingName(ctx, l, settings.HomeStation),
+			CallbackData: "ss:h",
+		})
+	}
+	if settings.WorkStation != nil {
+		favRow = append(favRow, models.InlineKeyboardButton{
+			Text:         "💼 " + b.stationSettingName(ctx, l, settings.WorkStation),
+			CallbackData: "ss:w",
+		})
+	}
+	if len(favRow) > 0 {
+		buttons = append(buttons, favRow)
+	}
 
 	// Recent stations (max 3)
 	if len(session.RecentStations) > 0 {
@@ -683,36 +906,38 @@ func (b *Bot) showStationSelection(ctx context.Context, botClient *bot.Bot, chat
 
 	// Text input fallback
 	buttons = append(buttons, []models.InlineKeyboardButton{
-		{Text: "⌨️ Ввести название", CallbackData: "text_input"},
+		{Text: l.T("button.type_station"), CallbackData: "text_input"},
 	})
 
 	// Navigation
 	navRow := []models.InlineKeyboardButton{}
 	if mode == "to" {
 		navRow = append(navRow, models.InlineKeyboardButton{
-			Text:         "◀️ Назад",
+			Text:         l.T("button.back"),
 			CallbackData: "b",
 		})
 	}
 	navRow = append(navRow, models.InlineKeyboardButton{
-		Text:         "❌ Отменить",
+		Text:         l.T("button.cancel"),
 		CallbackData: "x",
 	})
 	buttons = append(buttons, navRow)
 
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      chatID,
 		Text:        text,
 		// No ParseMode - avoid markdown escaping issues
 		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buttons},
 	})
 	if err != nil {
-		log.Printf("Error sending station selection: %v", err)
+		logging.FromContext(ctx).Error("send station selection failed", logging.Err(err))
 	}
 }
 
 // buildScheduleKeyboard builds paginated schedule keyboard
-func (b *Bot) buildScheduleKeyboard(schedules []*domain.Schedule, page int) [][]models.InlineKeyboardButton {
+// buildScheduleKeyboard renders a page of trains; editTripID switches the
+// actions to the trip change flow
+func (b *Bot) buildScheduleKeyboard(l *i18n.Localizer, schedules []*domain.Schedule, page int, loc *time.Location, editTripID int64) [][]models.InlineKeyboardButton {
 	buttons := [][]models.InlineKeyboardButton{}
 
 	pageSize := 5
@@ -726,9 +951,9 @@ func (b *Bot) buildScheduleKeyboard(schedules []*domain.Schedule, page int) [][]
 	// Train buttons for current page
 	for i := start; i < end; i++ {
 		sch := schedules[i]
-		depTime := sch.DepartureTime.Format("15:04")
-		arrTime := sch.ArrivalTime.Format("15:04")
-		duration := humanDurationFromSeconds(int(sch.Duration))
+		depTime := sch.DepartureTime.In(loc).Format("15:04")
+		arrTime := sch.ArrivalTime.In(loc).Format("15:04")
+		duration := humanDurationFromSeconds(l, int(sch.Duration))
 
 		buttonText := fmt.Sprintf("🚆 %s | %s → %s (%s)",
 			sch.TrainID, depTime, arrTime, duration)
@@ -767,11 +992,19 @@ func (b *Bot) buildScheduleKeyboard(schedules []*domain.Schedule, page int) [][]
 		buttons = append(buttons, navRow)
 	}
 
+	// Actions row
+	if editTripID != 0 {
+		// The route of a booked trip stays, only the date can be changed
+		return append(buttons, []models.InlineKeyboardButton{
+			{Text: l.T("button.other_date"), CallbackData: fmt.Sprintf("te:%d", editTripID)},
+			{Text: l.T("button.cancel"), CallbackData: "x"},
+		})
+	}
 	// Actions row
 	buttons = append(buttons, []models.InlineKeyboardButton{
-		{Text: "◀️ Назад", CallbackData: "b"},
-		{Text: "✏️ Изменить", CallbackData: "ef"},
-		{Text: "❌ Отменить", CallbackData: "x"},
+		{Text: l.T("button.back"), CallbackData: "b"},
+		{Text: l.T("button.edit"), CallbackData: "ef"},
+		{Text: l.T("button.cancel"), CallbackData: "x"},
 	})
 
 	return buttons
@@ -780,7 +1013,7 @@ func (b *Bot) buildScheduleKeyboard(schedules []*domain.Schedule, page int) [][]
 // handleBack handles back navigation
 func (b *Bot) handleBack(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession) {
 	if len(session.StateHistory) < 2 {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Нет предыдущего шага")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, b.localizer(ctx, &callbackQuery.From).T("common.no_previous"))
 		return
 	}
 
@@ -809,8 +1042,9 @@ func (b *Bot) handleBack(ctx context.Context, botClient *bot.Bot, callbackQuery
 
 // handleSelectStation handles station selection from inline keyboard
 func (b *Bot) handleSelectStation(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
 	if len(params) == 0 {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Ошибка: неверные параметры")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
 		return
 	}
 
@@ -822,28 +1056,55 @@ func (b *Bot) handleSelectStation(ctx context.Context, botClient *bot.Bot, callb
 		// Recent station
 		idx, err := strconv.Atoi(indexStr[1:])
 		if err != nil || idx < 0 || idx >= len(session.RecentStations) {
-			b.answerCallback(ctx, botClient, callbackQuery.ID, "Станция не найдена")
+			b.answerCallback(ctx, botClient, callbackQuery.ID, errorText(l, domain.ErrStationNotFound))
 			return
 		}
 		selectedStation = session.RecentStations[idx]
 		found = true
+	} else if strings.HasPrefix(indexStr, "m") {
+		// Station matching typed input
+		idx, err := strconv.Atoi(indexStr[1:])
+		if err != nil || idx < 0 || idx >= len(session.StationMatches) {
+			b.answerCallback(ctx, botClient, callbackQuery.ID, errorText(l, domain.ErrStationNotFound))
+			return
+		}
+		selectedStation = session.StationMatches[idx]
+		found = true
 	} else if strings.HasPrefix(indexStr, "p") {
 		// Popular station
 		idx, err := strconv.Atoi(indexStr[1:])
 		if err != nil {
-			b.answerCallback(ctx, botClient, callbackQuery.ID, "Ошибка формата")
+			b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_format"))
 			return
 		}
 		selectedStation, found = utils.GetStationByIndex(idx)
+	} else if indexStr == "h" || indexStr == "w" {
+		// Home or work station from settings
+		settings := b.loadSettings(ctx, callbackQuery.From.ID)
+		code := settings.HomeStation
+		if indexStr == "w" {
+			code = settings.WorkStation
+		}
+		if code != nil {
+			selectedStation, found = b.tripUC.FindStation(ctx, *code)
+		}
 	}
 
 	if !found {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Станция не найдена")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, errorText(l, domain.ErrStationNotFound))
+		return
+	}
+
+	if session.State == StateSelectingHome || session.State == StateSelectingWork {
+		b.saveStationSetting(ctx, callbackQuery.Message.Message.Chat.ID, &callbackQuery.From, session, selectedStation)
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.saved"))
 		return
 	}
 
 	// Add to recent stations
 	b.addToRecentStations(session, selectedStation)
+	// Only a new booking picks stations, a ✏️ change keeps the trip's route
+	session.EditTripID = 0
 
 	chatID := callbackQuery.Message.Message.Chat.ID
 
@@ -862,25 +1123,25 @@ func (b *Bot) handleSelectStation(ctx context.Context, botClient *bot.Bot, callb
 		session.ToName = selectedStation.DisplayName
 
 		// Search for schedules
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Поиск расписания...")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.searching"))
 
 		filteredOptions, err := b.tripUC.Search(ctx, session.From, session.To, session.Date)
 		if err != nil {
-			b.sendRecoverableError(ctx, botClient, chatID,
-				fmt.Sprintf("Ошибка поиска: %v", err),
+			b.sendRecoverableError(ctx, botClient, chatID, l,
+				l.T("trip.search_failed", errorText(l, err)),
 				[]models.InlineKeyboardButton{
-					{Text: "🔄 Попробовать снова", CallbackData: "ef"},
-					{Text: "❌ Отменить", CallbackData: "x"},
+					{Text: l.T("button.retry"), CallbackData: "ef"},
+					{Text: l.T("button.cancel"), CallbackData: "x"},
 				})
 			return
 		}
 
 		if len(filteredOptions) == 0 {
-			b.sendRecoverableError(ctx, botClient, chatID,
-				"Рейсы не найдены для этого маршрута.",
+			b.sendRecoverableError(ctx, botClient, chatID, l,
+				l.T("trip.no_trains"),
 				[]models.InlineKeyboardButton{
-					{Text: "🔄 Другие станции", CallbackData: "ef"},
-					{Text: "❌ Отменить", CallbackData: "x"},
+					{Text: l.T("button.other_stations"), CallbackData: "ef"},
+					{Text: l.T("button.cancel"), CallbackData: "x"},
 				})
 			return
 		}
@@ -894,14 +1155,16 @@ func (b *Bot) handleSelectStation(ctx context.Context, botClient *bot.Bot, callb
 
 // handleSchedulePage handles schedule pagination
 func (b *Bot) handleSchedulePage(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, params []string) {
+	settings := b.loadSettings(ctx, session.TelegramID)
+	l := i18n.For(settings.Language)
 	if len(params) == 0 {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Ошибка: неверная страница")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_page"))
 		return
 	}
 
 	page, err := strconv.Atoi(params[0])
 	if err != nil {
-		b.answerCallback(ctx, botClient, callbackQuery.ID, "Ошибка формата")
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_format"))
 		return
 	}
 
@@ -911,19 +1174,19 @@ func (b *Bot) handleSchedulePage(ctx context.Context, botClient *bot.Bot, callba
 	chatID := callbackQuery.Message.Message.Chat.ID
 	messageID := callbackQuery.Message.Message.ID
 
-	text := buildScheduleText(session.Schedule, session.FromName, session.ToName)
-	keyboard := b.buildScheduleKeyboard(session.Schedule, page)
+	msg := renderScreen(ctx, l, render.Schedule, buildScheduleData(l, session.Schedule, session.FromName, session.ToName, settings.ShowDetails, settings.Location()))
+	keyboard := b.buildScheduleKeyboard(l, session.Schedule, page, settings.Location(), session.EditTripID)
 
-	_, err = botClient.EditMessageText(ctx, &bot.EditMessageTextParams{
+	_, err = b.editMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatID,
 		MessageID:   messageID,
-		Text:        text,
-		// No ParseMode - avoid markdown escaping issues
+		Text:        msg.Text,
+		ParseMode:   msg.ParseMode,
 		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
 	})
 
 	if err != nil {
-		log.Printf("Error editing message: %v", err)
+		logging.FromContext(ctx).Error("edit message failed", logging.Err(err))
 		// Fallback: send new message
 		b.sendScheduleMessage(ctx, botClient, chatID, session)
 	}
@@ -933,23 +1196,25 @@ func (b *Bot) handleSchedulePage(ctx context.Context, botClient *bot.Bot, callba
 
 // sendScheduleMessage sends schedule message with pagination
 func (b *Bot) sendScheduleMessage(ctx context.Context, botClient *bot.Bot, chatID int64, session *UserSession) {
-	text := buildScheduleText(session.Schedule, session.FromName, session.ToName)
-	keyboard := b.buildScheduleKeyboard(session.Schedule, session.SchedulePage)
+	settings := b.loadSettings(ctx, session.TelegramID)
+	l := i18n.For(settings.Language)
+	msg := renderScreen(ctx, l, render.Schedule, buildScheduleData(l, session.Schedule, session.FromName, session.ToName, settings.ShowDetails, settings.Location()))
+	keyboard := b.buildScheduleKeyboard(l, session.Schedule, session.SchedulePage, settings.Location(), session.EditTripID)
 
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      chatID,
-		Text:        text,
-		// No ParseMode - avoid markdown escaping issues
+		Text:        msg.Text,
+		ParseMode:   msg.ParseMode,
 		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
 	})
 	if err != nil {
-		log.Printf("Error sending schedule: %v", err)
+		logging.FromContext(ctx).Error("send schedule failed", logging.Err(err))
 	}
 }
 
 // sendRecoverableError sends error message with recovery action buttons
-func (b *Bot) sendRecoverableError(ctx context.Context, botClient *bot.Bot, chatID int64, errorMsg string, actions []models.InlineKeyboardButton) {
-	text := fmt.Sprintf("⚠️ Ошибка\n\n%s\n\nЧто делать?", errorMsg)
+func (b *Bot) sendRecoverableError(ctx context.Context, botClient *bot.Bot, chatID int64, l *i18n.Localizer, errorMsg string, actions []models.InlineKeyboardButton) {
+	msg := renderScreen(ctx, l, render.RecoverableError, render.ErrorData{Text: errorMsg})
 
 	buttons := [][]models.InlineKeyboardButton{}
 
@@ -962,41 +1227,57 @@ func (b *Bot) sendRecoverableError(ctx context.Context, botClient *bot.Bot, chat
 		buttons = append(buttons, row)
 	}
 
-	_, err := botClient.SendMessage(ctx, &bot.SendMessageParams{
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      chatID,
-		Text:        text,
-		// No ParseMode - avoid markdown escaping issues
+		Text:        msg.Text,
+		ParseMode:   msg.ParseMode,
 		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buttons},
 	})
 	if err != nil {
-		log.Printf("Error sending recoverable error: %v", err)
+		logging.FromContext(ctx).Error("send error message failed", logging.Err(err))
 	}
 }
 
 func (b *Bot) RegisterHandlers() {
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, b.StartHandler)
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "/newtrip", bot.MatchTypeExact, b.NewTripHandler)
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "/mytrips", bot.MatchTypeExact, b.MyTripsHandler)
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, b.HelpHandler)
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "/cancel", bot.MatchTypeExact, b.CancelHandler)
-	b.client.RegisterHandler(bot.HandlerTypeMessageText, "", bot.MatchTypePrefix, b.TextMessageHandler)
-	b.client.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix, b.CallbackQueryHandler)
+	b.registerCommand("/start", b.StartHandler)
+	b.registerCommand("/newtrip", b.NewTripHandler)
+	b.registerCommand("/mytrips", b.privateOnly(b.MyTripsHandler))
+	b.registerCommand("/mystats", b.privateOnly(b.MyStatsHandler))
+	b.registerCommand("/export", b.privateOnly(b.ExportHandler))
+	b.registerCommand("/mydata", b.privateOnly(b.MyDataHandler))
+	b.registerCommand("/privacy", b.PrivacyHandler)
+	b.registerCommand("/deleteme", b.privateOnly(b.DeleteMeHandler))
+	b.registerCommand("/help", b.HelpHandler)
+	b.registerCommand("/cancel", b.CancelHandler)
+	b.registerCommand("/settings", b.privateOnly(b.SettingsHandler))
+	b.registerCommand("/language", b.privateOnly(b.LanguageHandler))
+	b.registerAdminCommand("stats", b.StatsHandler)
+	b.registerAdminCommand("broadcast", b.BroadcastHandler)
+	b.registerAdminCommand("user", b.UserInfoHandler)
+	b.registerAdminCommand("reminders_failed", b.FailedRemindersHandler)
+	// Documents carry no text and would otherwise match the text handler
+	b.client.RegisterHandlerMatchFunc(isDocument, instrument("document", "document", b.DocumentHandler), withSender)
+	b.client.RegisterHandler(bot.HandlerTypeMessageText, "", bot.MatchTypePrefix, instrument("text", "text", b.TextMessageHandler), withSender)
+	b.client.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix, b.CallbackQueryHandler, withSender)
 }
 
-func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) {
-	_, err := b.client.SendMessage(ctx, &bot.SendMessageParams{
+func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) error {
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatID,
 		Text:      text,
 	})
-	if err != nil {
-		log.Println(err)
-	}
+	return err
 }
 
-func sendErrorMessage(err error, ctx context.Context, botClient *bot.Bot, update *models.Update) error {
-	_, err = botClient.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   fmt.Sprintf("Error: %s", err.Error()),
+// sendErrorMessage shows err to the user in their language; errors without a
+// domain code get a generic text
+func (b *Bot) sendErrorMessage(ctx context.Context, update *models.Update, err error) error {
+	l := b.localizer(ctx, update.Message.From)
+	msg := renderScreen(ctx, l, render.Error, render.ErrorData{Text: errorText(l, err)})
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    update.Message.Chat.ID,
+			Text:      msg.Text,
+			ParseMode: msg.ParseMode,
 		})	
 	if err != nil {
 		return err
@@ -1004,20 +1285,40 @@ func sendErrorMessage(err error, ctx context.Context, botClient *bot.Bot, update
 	return nil
 }
 
-func humanDurationFromSeconds(sec int) string {
+// renderScreen renders a screen; if its template fails the user still gets a
+// generic error instead of nothing
+func renderScreen(ctx context.Context, l *i18n.Localizer, name string, data any) render.Message {
+	msg, err := render.Render(l, name, data)
+	if err != nil {
+		logging.FromContext(ctx).Error("render screen failed", "screen", name, logging.Err(err))
+		return render.Message{Text: l.T("err.internal")}
+	}
+	return msg
+}
+
+// errorText translates a domain error; other errors are internal and are not
+// shown to users
+func errorText(l *i18n.Localizer, err error) string {
+	if code := domain.ErrorCode(err); code != "" && l.Has("err."+code) {
+		return l.T("err." + code)
+	}
+	return l.T("err.internal")
+}
+
+func humanDurationFromSeconds(l *i18n.Localizer, sec int) string {
 	if sec < 60 {
-		return fmt.Sprintf("%d сек", sec)
+		return l.T("duration.seconds", sec)
 	}
 	mins := sec / 60
 	if mins < 60 {
-		return fmt.Sprintf("%d мин", mins)
+		return l.T("duration.minutes", mins)
 	}
 	h := mins / 60
 	m := mins % 60
 	if m == 0 {
-		return fmt.Sprintf("%dч (%d мин)", h, mins)
+		return l.T("duration.hours", h, mins)
 	}
-	return fmt.Sprintf("%dч %dм (%d мин)", h, m, mins)
+	return l.T("duration.hours_minutes", h, m, mins)
 }
 
 func cleanTitle(s string) string {
@@ -1027,47 +1328,21 @@ func cleanTitle(s string) string {
 }
 
 func escapeMarkdown(text string) string {
-	text = strings.ReplaceAll(text, "\\", "\\\\")
-	text = strings.ReplaceAll(text, "_", "\\_")
-	text = strings.ReplaceAll(text, "*", "\\*")
-	text = strings.ReplaceAll(text, "[", "\\[")
-	text = strings.ReplaceAll(text, "]", "\\]")
-	text = strings.ReplaceAll(text, "(", "\\(")
-	text = strings.ReplaceAll(text, ")", "\\)")
-	text = strings.ReplaceAll(text, "~", "\\~")
-	text = strings.ReplaceAll(text, "`", "\\`")
-	text = strings.ReplaceAll(text, ">", "\\>")
-	text = strings.ReplaceAll(text, "#", "\\#")
-	text = strings.ReplaceAll(text, "+", "\\+")
-	text = strings.ReplaceAll(text, "-", "\\-")
-	text = strings.ReplaceAll(text, "=", "\\=")
-	text = strings.ReplaceAll(text, "|", "\\|")
-	text = strings.ReplaceAll(text, "{", "\\{")
-	text = strings.ReplaceAll(text, "}", "\\}")
-	text = strings.ReplaceAll(text, ".", "\\.")
-	text = strings.ReplaceAll(text, "!", "\\!")
-	return text
+	return render.EscapeMarkdown(text)
 }
 
-func buildScheduleText(options []*domain.Schedule, from, to string) string {
-	var b strings.Builder
-	b.WriteString("🚆 Расписание рейсов\n\n")
-	fmt.Fprintf(&b, "📍 %s → %s\n\n", from, to)
-	b.WriteString("Выберите поезд:\n\n")
-
+// buildScheduleData prepares the schedule list; details adds titles and durations
+func buildScheduleData(l *i18n.Localizer, options []*domain.Schedule, from, to string, details bool, loc *time.Location) render.ScheduleData {
+	data := render.ScheduleData{From: from, To: to, Details: details}
 	for i, opt := range options {
-		num := i + 1
-		title := cleanTitle(opt.Title)
-
-		dep := opt.DepartureTime.Format("02.01.2006 15:04")
-		arr := opt.ArrivalTime.Format("15:04")
-		durationStr := humanDurationFromSeconds(int(opt.Duration))
-
-		fmt.Fprintf(&b, "%d. %s\n", num, title)
-		fmt.Fprintf(&b, "   🚆 Поезд: %s\n", opt.TrainID)
-		fmt.Fprintf(&b, "   🕒 %s → %s\n", dep, arr)
-		fmt.Fprintf(&b, "   ⏱ %s\n\n", durationStr)
+		data.Items = append(data.Items, render.ScheduleItem{
+			Num:       i + 1,
+			TrainID:   opt.TrainID,
+			Title:     opt.Title,
+			Departure: opt.DepartureTime.In(loc).Format("02.01.2006 15:04"),
+			Arrival:   opt.ArrivalTime.In(loc).Format("15:04"),
+			Duration:  humanDurationFromSeconds(l, int(opt.Duration)),
+		})
 	}
-
-	return b.String()
-}
\ No newline at end of file
+	return data
+}
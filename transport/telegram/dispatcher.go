@@ -0,0 +1,353 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Priority selects the dispatcher lane; lower values are sent first
+type Priority int
+
+const (
+	// PriorityInteractive is for replies to a user's own actions
+	PriorityInteractive Priority = iota
+	// PriorityReminder is for reminders sent by the worker
+	PriorityReminder
+	// PriorityBroadcast is for admin announcements
+	PriorityBroadcast
+
+	laneCount
+)
+
+var (
+	ErrQueueFull         = errors.New("telegram: outbound queue is full")
+	ErrDispatcherStopped = errors.New("telegram: dispatcher is stopped")
+)
+
+// DispatcherConfig sets the limits of outbound messages. Telegram allows
+// about 30 messages per second overall and one per second in a chat.
+type DispatcherConfig struct {
+	GlobalRate   int           // messages per second across all chats
+	ChatInterval time.Duration // minimum gap before a reminder or broadcast to a chat
+	QueueSize    int           // per lane; Send fails with ErrQueueFull beyond it
+	MaxRetries   int           // resends after a 429 before giving up
+}
+
+func DefaultDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		GlobalRate:   30,
+		ChatInterval: time.Second,
+		QueueSize:    1000,
+		MaxRetries:   3,
+	}
+}
+
+type outbound struct {
+	ctx     context.Context
+	prio    Priority
+	chatID  int64 // 0 if not known, then only the global limit applies
+	send    func(ctx context.Context) error
+	retries int
+	done    chan error
+}
+
+// Dispatcher sends outbound messages through priority lanes while keeping
+// to the global and per-chat rate limits. On 429 it pauses for retry_after
+// and resends. Run must be running for Send to make progress.
+type Dispatcher struct {
+	cfg            DispatcherConfig
+	globalInterval time.Duration
+
+	mu          sync.Mutex
+	lanes       [laneCount][]*outbound
+	chatNext    map[int64]time.Time // earliest next send per chat
+	lastSend    time.Time
+	pausedUntil time.Time
+	lastPrune   time.Time
+	stopped     bool
+
+	wake     chan struct{}
+	inflight sync.WaitGroup
+}
+
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	return &Dispatcher{
+		cfg:            cfg,
+		globalInterval: time.Second / time.Duration(cfg.GlobalRate),
+		chatNext:       make(map[int64]time.Time),
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Send queues send and waits until it ran or ctx is done. send receives
+// ctx and should make exactly one API call.
+func (d *Dispatcher) Send(ctx context.Context, prio Priority, chatID int64, send func(ctx context.Context) error) error {
+	m := &outbound{ctx: ctx, prio: prio, chatID: chatID, send: send, done: make(chan error, 1)}
+
+	d.mu.Lock()
+	switch {
+	case d.stopped:
+		d.mu.Unlock()
+		return ErrDispatcherStopped
+	case len(d.lanes[prio]) >= d.cfg.QueueSize:
+		d.mu.Unlock()
+		return ErrQueueFull
+	}
+	d.lanes[prio] = append(d.lanes[prio], m)
+	d.mu.Unlock()
+	d.signal()
+
+	select {
+	case err := <-m.done:
+		return err
+	case <-ctx.Done():
+		// The message is dropped when its turn comes
+		return ctx.Err()
+	}
+}
+
+// Len returns the number of queued messages across all lanes
+func (d *Dispatcher) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for p := range d.lanes {
+		n += len(d.lanes[p])
+	}
+	return n
+}
+
+func (d *Dispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches queued messages until ctx is done, then waits for sends
+// in flight and fails whatever is still queued
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		d.mu.Lock()
+		m, wait := d.next(time.Now())
+		d.mu.Unlock()
+
+		if m != nil {
+			d.inflight.Add(1)
+			go d.execute(m)
+			continue
+		}
+
+		if wait > 0 {
+			timer.Reset(wait)
+		}
+		select {
+		case <-ctx.Done():
+			d.stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) stop() {
+	d.mu.Lock()
+	d.stopped = true
+	var queued []*outbound
+	for p := range d.lanes {
+		queued = append(queued, d.lanes[p]...)
+		d.lanes[p] = nil
+	}
+	d.mu.Unlock()
+
+	for _, m := range queued {
+		m.done <- ErrDispatcherStopped
+	}
+	d.inflight.Wait()
+}
+
+// next returns the message to send now, or how long to wait before one may
+// be ready (0: until something is queued). Must be called with mu held.
+func (d *Dispatcher) next(now time.Time) (*outbound, time.Duration) {
+	if now.Before(d.pausedUntil) {
+		return nil, d.pausedUntil.Sub(now)
+	}
+	if ready := d.lastSend.Add(d.globalInterval); now.Before(ready) {
+		return nil, ready.Sub(now)
+	}
+	d.pruneChats(now)
+
+	var earliest time.Time
+	for p := range d.lanes {
+		lane := d.lanes[p]
+		for i := 0; i < len(lane); i++ {
+			m := lane[i]
+			if m.ctx.Err() != nil {
+				// The sender gave up waiting
+				lane = append(lane[:i], lane[i+1:]...)
+				i--
+				continue
+			}
+			// Replies answer the user's own action and go out at once;
+			// Telegram tolerates short bursts in a chat and a 429 is
+			// still retried
+			if ready, ok := d.chatNext[m.chatID]; ok && m.chatID != 0 && m.prio != PriorityInteractive && now.Before(ready) {
+				if earliest.IsZero() || ready.Before(earliest) {
+					earliest = ready
+				}
+				continue
+			}
+
+			d.lanes[p] = append(lane[:i], lane[i+1:]...)
+			d.lastSend = now
+			if m.chatID != 0 {
+				d.chatNext[m.chatID] = now.Add(d.cfg.ChatInterval)
+			}
+			return m, 0
+		}
+		d.lanes[p] = lane
+	}
+
+	if earliest.IsZero() {
+		return nil, 0
+	}
+	return nil, earliest.Sub(now)
+}
+
+// pruneChats forgets chats whose interval has passed; must be called with mu held
+func (d *Dispatcher) pruneChats(now time.Time) {
+	if now.Sub(d.lastPrune) < time.Minute {
+		return
+	}
+	d.lastPrune = now
+	for chatID, ready := range d.chatNext {
+		if !now.Before(ready) {
+			delete(d.chatNext, chatID)
+		}
+	}
+}
+
+func (d *Dispatcher) execute(m *outbound) {
+	defer d.inflight.Done()
+
+	err := m.send(m.ctx)
+
+	var tooMany *bot.TooManyRequestsError
+	if errors.As(err, &tooMany) && m.retries < d.cfg.MaxRetries {
+		// Telegram does not say whether the limit was per chat or global,
+		// so everything waits
+		resume := time.Now().Add(time.Duration(tooMany.RetryAfter) * time.Second)
+		d.mu.Lock()
+		if d.stopped {
+			d.mu.Unlock()
+			m.done <- err
+			return
+		}
+		if resume.After(d.pausedUntil) {
+			d.pausedUntil = resume
+		}
+		if m.chatID != 0 {
+			d.chatNext[m.chatID] = resume
+		}
+		m.retries++
+		// Back to the head of its lane to keep the order within a chat
+		d.lanes[m.prio] = append([]*outbound{m}, d.lanes[m.prio]...)
+		d.mu.Unlock()
+		d.signal()
+		return
+	}
+
+	m.done <- err
+}
+
+// SetDispatcherConfig replaces the outbound dispatcher with one using cfg;
+// call it before the dispatcher is run
+func (b *Bot) SetDispatcherConfig(cfg DispatcherConfig) {
+	b.dispatcher = NewDispatcher(cfg)
+}
+
+// Dispatcher returns the bot's outbound dispatcher, to be run alongside the bot
+func (b *Bot) Dispatcher() *Dispatcher {
+	return b.dispatcher
+}
+
+func (b *Bot) sendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error) {
+	return b.sendMessageWith(ctx, PriorityInteractive, params)
+}
+
+func (b *Bot) sendMessageWith(ctx context.Context, prio Priority, params *bot.SendMessageParams) (*models.Message, error) {
+	msg, err := dispatch(ctx, b.dispatcher, prio, chatIDOf(params.ChatID), func(ctx context.Context) (*models.Message, error) {
+		return b.client.SendMessage(ctx, params)
+	})
+	if err == nil {
+		b.bindButtons(ctx, msg)
+	}
+	return msg, err
+}
+
+func (b *Bot) editMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error) {
+	msg, err := dispatch(ctx, b.dispatcher, PriorityInteractive, chatIDOf(params.ChatID), func(ctx context.Context) (*models.Message, error) {
+		return b.client.EditMessageText(ctx, params)
+	})
+	if err == nil {
+		b.bindButtons(ctx, msg)
+	}
+	return msg, err
+}
+
+// sendDocument uploads data as a file; the reader is recreated on every
+// attempt so a retried send does not upload an empty file
+func (b *Bot) sendDocument(ctx context.Context, chatID int64, filename string, data []byte, params *bot.SendDocumentParams) (*models.Message, error) {
+	return dispatch(ctx, b.dispatcher, PriorityInteractive, chatID, func(ctx context.Context) (*models.Message, error) {
+		p := *params
+		p.ChatID = chatID
+		p.Document = &models.InputFileUpload{Filename: filename, Data: bytes.NewReader(data)}
+		return b.client.SendDocument(ctx, &p)
+	})
+}
+
+// dispatch sends through d and returns the call's result. The result is
+// handed over on a channel and read only after a successful Send: when ctx
+// is done Send returns while the call may still be running.
+func dispatch[T any](ctx context.Context, d *Dispatcher, prio Priority, chatID int64, call func(ctx context.Context) (T, error)) (T, error) {
+	result := make(chan T, 1) // a call succeeds at most once, retries follow errors
+	err := d.Send(ctx, prio, chatID, func(ctx context.Context) error {
+		v, err := call(ctx)
+		if err == nil {
+			result <- v
+		}
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return <-result, nil
+}
+
+// chatIDOf returns the numeric chat ID, 0 for @channel usernames
+func chatIDOf(chatID any) int64 {
+	switch id := chatID.(type) {
+	case int64:
+		return id
+	case int:
+		return int64(id)
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const exportFilename = "trips.ics"
+
+// ExportHandler sends the user's upcoming trips as an .ics file
+func (b *Bot) ExportHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	telegramID := update.Message.From.ID
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+	l := b.localizer(ctx, update.Message.From)
+
+	data, count, err := b.calendarUC.Export(ctx, user.ID, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).Error("export calendar failed", logging.Err(err))
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	caption := l.T("export.caption", count)
+	if count == 0 {
+		caption = l.T("export.empty")
+	}
+
+	params := &bot.SendDocumentParams{
+		Caption:   caption,
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if b.calendarUC.LinksEnabled() {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: l.T("export.link_button"), CallbackData: "cl"}},
+		}}
+	}
+	if _, err := b.sendDocument(ctx, update.Message.Chat.ID, exportFilename, data, params); err != nil {
+		logging.FromContext(ctx).Error("send calendar failed", logging.Err(err))
+	}
+}
+
+// handleCalendarLink sends the user's secret subscription URL
+func (b *Bot) handleCalendarLink(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+	url, err := b.calendarUC.SubscriptionURL(ctx, user.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("calendar subscription link failed", logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    callbackQuery.From.ID,
+		Text:      l.T("export.link", url),
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: l.T("export.revoke_button"), CallbackData: "cr"}},
+		}},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// handleCalendarRevoke invalidates the subscription URL
+func (b *Bot) handleCalendarRevoke(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+	if err := b.calendarUC.RevokeSubscription(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Error("revoke calendar link failed", logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("export.revoke_failed"))
+		return
+	}
+
+	if msg := callbackQuery.Message.Message; msg != nil {
+		_, err = b.editMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: msg.ID,
+			Text:      l.T("export.revoked"),
+			ParseMode: models.ParseModeMarkdown,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("edit revoked link message failed", logging.Err(err))
+		}
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("export.revoked_answer"))
+}
@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// groupButtonTTL is how long buttons sent to a group stay bound to the user
+// they were sent for
+const groupButtonTTL = 48 * time.Hour
+
+// sharedActions are callbacks any group member may press; their handlers
+// act on the presser's own trip or reminder
+var sharedActions = map[string]bool{"tj": true, "rs": true, "ra": true, "noop": true}
+
+// groupMessage identifies a bot message in a group chat
+type groupMessage struct {
+	chatID    int64
+	messageID int
+}
+
+// buttonOwner is the user who may press a group message's buttons
+type buttonOwner struct {
+	telegramID int64
+	sentAt     time.Time
+}
+
+type senderKey struct{}
+
+func isGroupChat(chat models.Chat) bool {
+	return chat.Type == models.ChatTypeGroup || chat.Type == models.ChatTypeSupergroup
+}
+
+// withSender remembers who sent the update, so buttons the bot sends to a
+// group while handling it are bound to that user
+func withSender(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+		switch {
+		case update.Message != nil && update.Message.From != nil:
+			ctx = context.WithValue(ctx, senderKey{}, update.Message.From.ID)
+		case update.CallbackQuery != nil:
+			ctx = context.WithValue(ctx, senderKey{}, update.CallbackQuery.From.ID)
+		}
+		next(ctx, botClient, update)
+	}
+}
+
+// privateOnly keeps a personal command out of group chats: its reply would
+// show the user's data to the group and its buttons act on whoever presses
+// them
+func (b *Bot) privateOnly(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+		if !isGroupChat(update.Message.Chat) {
+			next(ctx, botClient, update)
+			return
+		}
+		_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          update.Message.Chat.ID,
+			Text:            b.localizer(ctx, update.Message.From).T("group.private_only"),
+			ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+		}
+	}
+}
+
+// bindButtons makes the buttons of msg, sent to a group while handling a
+// user's update, pressable only by that user
+func (b *Bot) bindButtons(ctx context.Context, msg *models.Message) {
+	sender, ok := ctx.Value(senderKey{}).(int64)
+	if !ok || msg == nil || msg.ReplyMarkup == nil || !isGroupChat(msg.Chat) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.groupButtons[groupMessage{chatID: msg.Chat.ID, messageID: msg.ID}] = buttonOwner{telegramID: sender, sentAt: time.Now()}
+}
+
+// mayPress reports whether the user pressing a button is allowed to. In
+// groups only the user the message was sent for may, except for shared
+// actions; buttons whose owner is unknown, e.g. after a restart, are refused.
+func (b *Bot) mayPress(callbackQuery *models.CallbackQuery, action string) bool {
+	msg := callbackQuery.Message.Message
+	if msg == nil || !isGroupChat(msg.Chat) || sharedActions[action] {
+		return true
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	owner, ok := b.groupButtons[groupMessage{chatID: msg.Chat.ID, messageID: msg.ID}]
+	return ok && owner.telegramID == callbackQuery.From.ID
+}
+
+// evictGroupButtons forgets bindings older than groupButtonTTL; the caller
+// holds b.mu
+func (b *Bot) evictGroupButtons(now time.Time) {
+	for key, owner := range b.groupButtons {
+		if now.Sub(owner.sentAt) > groupButtonTTL {
+			delete(b.groupButtons, key)
+		}
+	}
+}
+
+func joinTripButton(l *i18n.Localizer, tripID int64) models.InlineKeyboardButton {
+	return models.InlineKeyboardButton{Text: l.T("button.join_trip"), CallbackData: "tj:" + strconv.FormatInt(tripID, 10)}
+}
+
+// handleTripJoin adds a group member to a trip booked in the group. Members
+// who never wrote to the bot are registered here and get their reminders in
+// the group.
+// Format: tj:tripID
+func (b *Bot) handleTripJoin(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) == 0 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
+		return
+	}
+	tripID, err := strconv.ParseInt(params[0], 10, 64)
+	if err != nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_format"))
+		return
+	}
+
+	var chat models.Chat
+	if msg := callbackQuery.Message.Message; msg != nil {
+		chat = msg.Chat
+	}
+	user, err := b.ensureUser(ctx, &callbackQuery.From, chat)
+	if err != nil {
+		logging.FromContext(ctx).Error("register user failed", logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	if _, err := b.tripUC.JoinTrip(ctx, tripID, user.ID, time.Now()); err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("trip.joined_answer"))
+
+	if chat.ID == 0 {
+		return
+	}
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chat.ID,
+		Text:      l.T("trip.joined", escapeMarkdown(callbackQuery.From.FirstName)),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
@@ -0,0 +1,330 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/usecase"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Trip history tabs in "th:<tab>:<page>" callbacks
+const (
+	historyUpcoming = "u"
+	historyPast     = "p"
+)
+
+func (b *Bot) MyTripsHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	telegramID := update.Message.From.ID
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	text, keyboard, err := b.renderTripHistory(ctx, user.ID, telegramID, historyUpcoming, 0)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if keyboard != nil {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
+	if _, err := b.sendMessage(ctx, params); err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// handleTripHistory switches the history tab or page in place
+func (b *Bot) handleTripHistory(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 2 || callbackQuery.Message.Message == nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
+		return
+	}
+	page, err := strconv.Atoi(params[1])
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_format"))
+		return
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	text, keyboard, err := b.renderTripHistory(ctx, user.ID, callbackQuery.From.ID, params[0], page)
+	if err != nil {
+		logging.FromContext(ctx).Error("load trip history failed", logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("history.load_failed"))
+		return
+	}
+
+	msg := callbackQuery.Message.Message
+	edit := &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if keyboard != nil {
+		edit.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
+	if _, err := b.editMessageText(ctx, edit); err != nil {
+		logging.FromContext(ctx).Warn("edit trip history failed", logging.Err(err))
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// handleTripView shows a single trip in place, e.g. from "already booked"
+func (b *Bot) handleTripView(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	trip, ok := b.callbackTrip(ctx, botClient, callbackQuery, params)
+	if !ok {
+		return
+	}
+	changes, err := b.tripUC.TripChanges(ctx, trip.UserID, trip.ID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("load trip changes failed", logging.Err(err))
+	}
+
+	settings := b.loadSettings(ctx, callbackQuery.From.ID)
+	l, loc := i18n.For(settings.Language), settings.Location()
+	text := l.T("trip.view_title", trip.ID) +
+		tripDetails(l, trip, b.stationLabel(ctx, trip.From, trip.FromName), b.stationLabel(ctx, trip.To, trip.ToName), loc, "")
+	if len(changes) > 0 {
+		// The first change keeps the train the trip was originally booked on
+		first := changes[0]
+		original := first.OldDeparture.In(loc).Format("02.01.2006 15:04")
+		if first.OldTrainNumber != "" {
+			original = first.OldTrainNumber + ", " + original
+		}
+		text += l.N("trip.changed", len(changes), len(changes), escapeMarkdown(original))
+	}
+
+	keyboard := [][]models.InlineKeyboardButton{}
+	if trip.DepartureTime.After(time.Now()) {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{{Text: l.T("button.edit"), CallbackData: fmt.Sprintf("te:%d", trip.ID)}})
+	}
+	keyboard = append(keyboard, []models.InlineKeyboardButton{{Text: l.T("button.all_trips"), CallbackData: "th:" + historyUpcoming + ":0"}})
+
+	msg := callbackQuery.Message.Message
+	_, err = b.editMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("edit trip view failed", logging.Err(err))
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// renderTripHistory builds one page of /mytrips with tab and page buttons
+func (b *Bot) renderTripHistory(ctx context.Context, userID, telegramID int64, tab string, page int) (string, [][]models.InlineKeyboardButton, error) {
+	past := tab == historyPast
+	if !past {
+		tab = historyUpcoming
+	}
+	history, err := b.tripUC.History(ctx, userID, past, page, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+
+	settings := b.loadSettings(ctx, telegramID)
+	l, loc := i18n.For(settings.Language), settings.Location()
+	if history.Upcoming+history.Past == 0 {
+		return l.T("history.empty"), nil, nil
+	}
+
+	var sb strings.Builder
+	if past {
+		sb.WriteString(l.T("history.past_title"))
+	} else {
+		sb.WriteString(l.T("history.upcoming_title"))
+	}
+	if len(history.Trips) == 0 {
+		if past {
+			sb.WriteString(l.T("history.past_none"))
+		} else {
+			sb.WriteString(l.T("history.upcoming_none"))
+		}
+	}
+
+	for i, trip := range history.Trips {
+		sb.WriteString(l.T("history.item", history.Page*usecase.HistoryPageSize+i+1, trip.ID))
+		sb.WriteString(tripDetails(l, trip, b.stationLabel(ctx, trip.From, trip.FromName), b.stationLabel(ctx, trip.To, trip.ToName), loc, "   "))
+		if trip.UserID != userID {
+			sb.WriteString(l.T("history.joined"))
+		}
+		sb.WriteString("\n")
+	}
+	if history.Pages > 1 {
+		sb.WriteString(l.T("history.page", history.Page+1, history.Pages))
+	}
+
+	var keyboard [][]models.InlineKeyboardButton
+	if !past && len(history.Trips) > 0 {
+		var edits []models.InlineKeyboardButton
+		for _, trip := range history.Trips {
+			// Joined group trips can only be changed by whoever booked them
+			if trip.UserID != userID {
+				continue
+			}
+			edits = append(edits, models.InlineKeyboardButton{Text: fmt.Sprintf("✏️ #%d", trip.ID), CallbackData: fmt.Sprintf("te:%d", trip.ID)})
+		}
+		if len(edits) > 0 {
+			keyboard = append(keyboard, edits)
+		}
+	}
+	if history.Pages > 1 {
+		var nav []models.InlineKeyboardButton
+		if history.Page > 0 {
+			nav = append(nav, models.InlineKeyboardButton{Text: "⬅️", CallbackData: fmt.Sprintf("th:%s:%d", tab, history.Page-1)})
+		}
+		nav = append(nav, models.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", history.Page+1, history.Pages), CallbackData: "noop"})
+		if history.Page < history.Pages-1 {
+			nav = append(nav, models.InlineKeyboardButton{Text: "➡️", CallbackData: fmt.Sprintf("th:%s:%d", tab, history.Page+1)})
+		}
+		keyboard = append(keyboard, nav)
+	}
+	if past {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: l.T("history.tab_upcoming", history.Upcoming), CallbackData: "th:" + historyUpcoming + ":0"},
+		})
+	} else {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: l.T("history.tab_past", history.Past), CallbackData: "th:" + historyPast + ":0"},
+		})
+	}
+	return sb.String(), keyboard, nil
+}
+
+func (b *Bot) MyStatsHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	telegramID := update.Message.From.ID
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	settings := b.loadSettings(ctx, telegramID)
+	stats, err := b.tripUC.Stats(ctx, user.ID, time.Now(), settings.Location())
+	if err != nil {
+		logging.FromContext(ctx).Error("load user stats failed", logging.Err(err))
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      b.renderUserStats(ctx, i18n.For(settings.Language), stats),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+func (b *Bot) renderUserStats(ctx context.Context, l *i18n.Localizer, stats *usecase.UserStats) string {
+	var sb strings.Builder
+	sb.WriteString(l.T("stats.title"))
+
+	if stats.Totals.Trips == 0 {
+		sb.WriteString(l.T("stats.empty"))
+		return sb.String()
+	}
+
+	sb.WriteString(l.T("stats.trips", stats.Totals.Trips))
+	if stats.Totals.TrainTime > 0 {
+		sb.WriteString(l.T("stats.train_time", escapeMarkdown(formatTrainTime(l, stats.Totals.TrainTime))))
+	}
+	sb.WriteString(l.T("stats.pages", stats.Totals.PagesRead))
+
+	sb.WriteString(l.T("stats.by_month"))
+	for _, m := range stats.Months {
+		label := fmt.Sprintf("%s %d", l.T(fmt.Sprintf("month.%d", m.Month.Month())), m.Month.Year())
+		sb.WriteString(fmt.Sprintf("`%-8s` %s %d\n", label, strings.Repeat("▇", min(m.Trips, 20)), m.Trips))
+	}
+
+	if len(stats.Routes) > 0 {
+		sb.WriteString(l.T("stats.routes"))
+		for i, r := range stats.Routes {
+			sb.WriteString(fmt.Sprintf("%d\\. %s → %s — %d\n", i+1,
+				escapeMarkdown(b.stationLabel(ctx, r.From, r.FromName)), escapeMarkdown(b.stationLabel(ctx, r.To, r.ToName)), r.Trips))
+		}
+	}
+	return sb.String()
+}
+
+// stationLabel returns the station name saved with the trip. Trips saved
+// before names were stored fall back to the station list, then to the code.
+func (b *Bot) stationLabel(ctx context.Context, code, name string) string {
+	if name != "" {
+		return name
+	}
+	if station, ok := b.tripUC.FindStation(ctx, code); ok && station.Code == code {
+		return station.DisplayName
+	}
+	return code
+}
+
+// tripDetails renders the saved train snapshot as MarkdownV2 lines; from and
+// to are the unescaped station names
+func tripDetails(l *i18n.Localizer, trip *domain.Trip, from, to string, loc *time.Location, indent string) string {
+	var sb strings.Builder
+	if trip.TrainNumber != "" {
+		train := "*" + escapeMarkdown(trip.TrainNumber) + "*"
+		if title := cleanTitle(trip.TrainTitle); title != "" {
+			train += " " + escapeMarkdown(title)
+		}
+		sb.WriteString(indent + l.T("details.train", train) + "\n")
+	}
+	sb.WriteString(indent + l.T("details.route", escapeMarkdown(from), escapeMarkdown(to)) + "\n")
+
+	dep := trip.DepartureTime.In(loc)
+	when := dep.Format("02.01.2006 15:04")
+	if !trip.ArrivalTime.IsZero() {
+		arr := trip.ArrivalTime.In(loc)
+		if arr.YearDay() == dep.YearDay() && arr.Year() == dep.Year() {
+			when += " → " + arr.Format("15:04")
+		} else {
+			when += " → " + arr.Format("02.01.2006 15:04")
+		}
+	}
+	sb.WriteString(fmt.Sprintf("%s🕒 %s\n", indent, escapeMarkdown(when)))
+	if trip.Duration > 0 {
+		sb.WriteString(indent + l.T("details.duration", escapeMarkdown(humanDurationFromSeconds(l, int(trip.Duration.Seconds())))) + "\n")
+	}
+	return sb.String()
+}
+
+// formatTrainTime renders a total like "3 d 4 h 15 min"
+func formatTrainTime(l *i18n.Localizer, d time.Duration) string {
+	mins := int(d.Minutes())
+	days, hours, mins := mins/(24*60), mins/60%24, mins%60
+	switch {
+	case days > 0:
+		return l.T("duration.total_days", days, hours, mins)
+	case hours > 0:
+		return l.T("duration.total_hours", hours, mins)
+	}
+	return l.T("duration.minutes", mins)
+}
@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// LoggingOptions routes the client's own errors and debug output through
+// slog and attaches per-update attributes to the handler context
+func LoggingOptions() []bot.Option {
+	return []bot.Option{
+		bot.WithMiddlewares(updateLogging),
+		bot.WithErrorsHandler(func(err error) {
+			slog.Error("telegram client error", logging.Err(err))
+		}),
+		bot.WithDebugHandler(func(format string, args ...any) {
+			slog.Debug(fmt.Sprintf(format, args...))
+		}),
+		// The library default dumps the whole update, including user text
+		bot.WithDefaultHandler(func(ctx context.Context, _ *bot.Bot, update *models.Update) {
+			logging.FromContext(ctx).Debug("unhandled update")
+		}),
+	}
+}
+
+func updateLogging(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+		args := []any{slog.Int64("update_id", update.ID)}
+		switch {
+		case update.Message != nil:
+			args = append(args, slog.Int64("chat_id", update.Message.Chat.ID))
+			if update.Message.From != nil {
+				args = append(args, slog.Int64("telegram_id", update.Message.From.ID))
+			}
+		case update.CallbackQuery != nil:
+			action, _ := ParseCallback(update.CallbackQuery.Data)
+			args = append(args,
+				slog.Int64("telegram_id", update.CallbackQuery.From.ID),
+				slog.String("callback_action", action),
+			)
+		}
+		ctx = logging.With(ctx, args...)
+		logging.FromContext(ctx).Debug("update received")
+		next(ctx, botClient, update)
+	}
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/metrics"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// registerCommand registers an exact-match command handler that is counted
+// under the command's name
+func (b *Bot) registerCommand(command string, handler bot.HandlerFunc) {
+	b.client.RegisterHandlerMatchFunc(matchCommand(command), instrument("command", command, handler), withSender)
+}
+
+// matchCommand matches the command alone, also in the /command@bot form
+// Telegram sends from group menus. With privacy mode the bot only gets
+// commands addressed to itself, so the suffix isn't checked.
+func matchCommand(command string) bot.MatchFunc {
+	return func(update *models.Update) bool {
+		if update.Message == nil {
+			return false
+		}
+		text := update.Message.Text
+		if text == command {
+			return true
+		}
+		name, mention, ok := strings.Cut(text, "@")
+		return ok && name == command && mention != "" && !strings.ContainsAny(mention, " \n")
+	}
+}
+
+func instrument(kind, action string, next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+		start := time.Now()
+		defer func() { metrics.ObserveUpdate(kind, action, time.Since(start)) }()
+		next(ctx, botClient, update)
+	}
+}
+
+// SessionCount returns the number of dialog sessions kept in memory
+func (b *Bot) SessionCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.userSessions)
+}
@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/usecase"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// maxImportFileSize limits book import uploads
+	maxImportFileSize = 1 << 20
+	downloadTimeout   = 30 * time.Second
+	// importErrorsShown caps the rejected rows listed in the import report
+	importErrorsShown = 10
+)
+
+var errImportFileTooLarge = domain.NewError("import.file_too_large", "import file is too large")
+
+func isDocument(update *models.Update) bool {
+	return update.Message != nil && update.Message.Document != nil
+}
+
+// MyDataHandler offers exporting the user's data and importing books
+func (b *Bot) MyDataHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	l := b.localizer(ctx, update.Message.From)
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      l.T("data.intro"),
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: l.T("data.export_csv"), CallbackData: "dx:" + string(usecase.ExportCSV)},
+				{Text: l.T("data.export_json"), CallbackData: "dx:" + string(usecase.ExportJSON)},
+			},
+			{{Text: l.T("data.import_button"), CallbackData: "bi"}},
+		}},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// handleDataExport sends the export files as documents
+func (b *Bot) handleDataExport(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 1 {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
+		return
+	}
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	files, err := b.dataUC.Export(ctx, user.ID, usecase.ExportFormat(params[0]), time.Now())
+	if errors.Is(err, usecase.ErrExportFormat) {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("export user data failed", logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("data.export_failed"))
+		return
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("data.export_ready"))
+
+	for _, f := range files {
+		params := &bot.SendDocumentParams{Caption: l.T("data.file_caption", f.Name, f.Rows)}
+		if _, err := b.sendDocument(ctx, callbackQuery.From.ID, f.Name, f.Data, params); err != nil {
+			logging.FromContext(ctx).Error("send export file failed", "file", f.Name, logging.Err(err))
+			return
+		}
+	}
+}
+
+// handleBookImportStart waits for the user to send a CSV file
+func (b *Bot) handleBookImportStart(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession) {
+	session.State = StateWaitingBookImport
+	session.StateHistory = []UserState{StateWaitingBookImport}
+
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    callbackQuery.From.ID,
+		Text:      b.localizer(ctx, &callbackQuery.From).T("data.import_prompt"),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// DocumentHandler handles uploaded files; only book imports are accepted
+func (b *Bot) DocumentHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	msg := update.Message
+	session := b.getSession(msg.From.ID)
+	l := b.localizer(ctx, msg.From)
+	if session.State != StateWaitingBookImport {
+		b.reply(ctx, msg.Chat.ID, l.T("data.import_hint"))
+		return
+	}
+
+	doc := msg.Document
+	if !strings.EqualFold(path.Ext(doc.FileName), ".csv") && !strings.Contains(doc.MimeType, "csv") {
+		b.reply(ctx, msg.Chat.ID, l.T("data.import_need_csv"))
+		return
+	}
+	if doc.FileSize > maxImportFileSize {
+		b.reply(ctx, msg.Chat.ID, errorText(l, errImportFileTooLarge))
+		return
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, msg.From.ID)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+	data, err := b.downloadFile(ctx, doc.FileID, maxImportFileSize)
+	if err != nil {
+		logging.FromContext(ctx).Error("download import file failed", logging.Err(err))
+		b.reply(ctx, msg.Chat.ID, l.T("data.download_failed"))
+		return
+	}
+
+	res, err := b.bookUC.Import(ctx, user.ID, bytes.NewReader(data))
+	if err != nil && res == nil {
+		// The file itself was rejected; let the user send another one
+		b.reply(ctx, msg.Chat.ID, l.T("common.error", errorText(l, err)))
+		return
+	}
+	session.State = StateNone
+	session.StateHistory = nil
+	if err != nil {
+		logging.FromContext(ctx).Error("import books failed", logging.Err(err))
+	}
+
+	_, sendErr := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    msg.Chat.ID,
+		Text:      renderBookImport(l, res, err),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if sendErr != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(sendErr))
+	}
+}
+
+func renderBookImport(l *i18n.Localizer, res *usecase.BookImportResult, err error) string {
+	var sb strings.Builder
+	sb.WriteString(l.T("data.import_title"))
+	switch {
+	case err != nil && res.AbortedLine > 0:
+		sb.WriteString(l.T("data.import_aborted_at", res.AbortedLine))
+		return sb.String()
+	case err != nil:
+		sb.WriteString(l.T("data.import_aborted"))
+		return sb.String()
+	}
+	sb.WriteString(l.T("data.import_added", res.Imported))
+	if res.Duplicates > 0 {
+		sb.WriteString(l.T("data.import_duplicate", res.Duplicates))
+	}
+	if len(res.Failed) > 0 {
+		sb.WriteString(l.T("data.import_skipped", len(res.Failed)))
+		for i, f := range res.Failed {
+			if i == importErrorsShown {
+				sb.WriteString(l.T("data.import_more", len(res.Failed)-importErrorsShown))
+				break
+			}
+			sb.WriteString(escapeMarkdown(l.T("data.import_row", f.Line, errorText(l, f.Err))) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// reply sends a plain text message
+func (b *Bot) reply(ctx context.Context, chatID int64, text string) {
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// downloadFile fetches an uploaded file, failing if it exceeds limit bytes
+func (b *Bot) downloadFile(ctx context.Context, fileID string, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	file, err := b.client.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.client.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL contains the bot token, drop it from the error
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errImportFileTooLarge
+	}
+	return data, nil
+}
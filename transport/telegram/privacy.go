@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// deleteConfirmTTL is how long the /deleteme confirmation button stays valid
+const deleteConfirmTTL = 10 * time.Minute
+
+// PrivacyHandler describes what the bot stores and how to remove it
+func (b *Bot) PrivacyHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	text := b.localizer(ctx, update.Message.From).T("privacy.text")
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// DeleteMeHandler asks to confirm deleting the account
+func (b *Bot) DeleteMeHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	if _, err := b.userUC.GetUserByTelegramID(ctx, update.Message.From.ID); err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	l := b.localizer(ctx, update.Message.From)
+	text := l.T("delete.prompt")
+	stamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: l.T("delete.confirm_button"), CallbackData: "dd:" + stamp}},
+			{{Text: l.T("delete.cancel_button"), CallbackData: "dn"}},
+		}},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// handleDeleteConfirm deletes the account after the user confirmed it
+func (b *Bot) handleDeleteConfirm(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 1 {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
+		return
+	}
+	stamp, err := strconv.ParseInt(params[0], 10, 64)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_format"))
+		return
+	}
+	if time.Since(time.Unix(stamp, 0)) > deleteConfirmTTL {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("delete.expired"))
+		return
+	}
+
+	telegramID := callbackQuery.From.ID
+	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("delete.already"))
+		return
+	}
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	if err := b.userUC.DeleteAccount(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Error("delete account failed", "user_id", user.ID, logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("delete.failed"))
+		return
+	}
+	b.clearSession(telegramID)
+	logging.FromContext(ctx).Info("account deleted", "user_id", user.ID)
+
+	b.finishDeleteDialog(ctx, callbackQuery, l.T("delete.done"))
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("delete.done_answer"))
+}
+
+// handleDeleteCancel keeps the account
+func (b *Bot) handleDeleteCancel(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery) {
+	b.finishDeleteDialog(ctx, callbackQuery, b.localizer(ctx, &callbackQuery.From).T("delete.cancelled"))
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// finishDeleteDialog replaces the confirmation so its buttons can't be reused
+func (b *Bot) finishDeleteDialog(ctx context.Context, callbackQuery *models.CallbackQuery, text string) {
+	msg := callbackQuery.Message.Message
+	if msg == nil {
+		return
+	}
+	_, err := b.editMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("edit delete confirmation failed", logging.Err(err))
+	}
+}
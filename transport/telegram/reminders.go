@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/transport/telegram/render"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// SendReminder delivers the user's reminder with snooze/acknowledge buttons.
+// In a group chat the user is mentioned, the buttons still only work for them.
+func (b *Bot) SendReminder(ctx context.Context, chatID int64, user *domain.User, reminder *domain.Reminder, silent bool) error {
+	l := i18n.For(b.loadSettings(ctx, user.TelegramID).Language)
+	data := render.ReminderData{Text: reminder.Message}
+	if chatID != user.ChatID && chatID != user.TelegramID {
+		data.MentionID = user.TelegramID
+		data.MentionName = user.Name
+	}
+	msg := renderScreen(ctx, l, render.Reminder, data)
+	_, err := b.sendMessageWith(ctx, PriorityReminder, &bot.SendMessageParams{
+		ChatID:              chatID,
+		Text:                msg.Text,
+		ParseMode:           msg.ParseMode,
+		DisableNotification: silent,
+		ReplyMarkup:         &models.InlineKeyboardMarkup{InlineKeyboard: buildReminderKeyboard(l, reminder.ID)},
+	})
+	return err
+}
+
+// IsBlockedByUser reports whether a send failed because the user blocked the bot
+// or the chat is otherwise unreachable for it
+func IsBlockedByUser(err error) bool {
+	return errors.Is(err, bot.ErrorForbidden)
+}
+
+func buildReminderKeyboard(l *i18n.Localizer, reminderID int64) [][]models.InlineKeyboardButton {
+	return [][]models.InlineKeyboardButton{
+		{
+			{Text: l.T("reminder.snooze_5"), CallbackData: fmt.Sprintf("rs:%d:5", reminderID)},
+			{Text: l.T("reminder.snooze_10"), CallbackData: fmt.Sprintf("rs:%d:10", reminderID)},
+		},
+		{
+			{Text: l.T("reminder.ack"), CallbackData: fmt.Sprintf("ra:%d", reminderID)},
+		},
+	}
+}
+
+// handleReminderSnooze reschedules a delivered reminder
+// Format: rs:reminderID:minutes
+func (b *Bot) handleReminderSnooze(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 2 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
+		return
+	}
+	reminderID, err1 := strconv.ParseInt(params[0], 10, 64)
+	minutes, err2 := strconv.Atoi(params[1])
+	if err1 != nil || err2 != nil || minutes <= 0 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_format"))
+		return
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	if err := b.reminderUC.Snooze(ctx, user.ID, reminderID, time.Duration(minutes)*time.Minute); err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+
+	b.closeReminderMessage(ctx, botClient, callbackQuery, l.T("reminder.snoozed", minutes))
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("reminder.snoozed_answer"))
+}
+
+// handleReminderAck marks a delivered reminder as acknowledged
+// Format: ra:reminderID
+func (b *Bot) handleReminderAck(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) == 0 {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
+		return
+	}
+	reminderID, err := strconv.ParseInt(params[0], 10, 64)
+	if err != nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_format"))
+		return
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	if err := b.reminderUC.Acknowledge(ctx, user.ID, reminderID); err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+
+	b.closeReminderMessage(ctx, botClient, callbackQuery, l.T("reminder.acked"))
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// closeReminderMessage removes reminder buttons and appends a status line
+func (b *Bot) closeReminderMessage(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, status string) {
+	msg := callbackQuery.Message.Message
+	if msg == nil {
+		return
+	}
+	_, err := b.editMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      msg.Text + "\n\n" + status,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("edit reminder message failed", logging.Err(err))
+	}
+}
@@ -0,0 +1,44 @@
+package render
+
+import "strings"
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"_", `\_`,
+	"*", `\*`,
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"~", `\~`,
+	"`", "\\`",
+	">", `\>`,
+	"#", `\#`,
+	"+", `\+`,
+	"-", `\-`,
+	"=", `\=`,
+	"|", `\|`,
+	"{", `\{`,
+	"}", `\}`,
+	".", `\.`,
+	"!", `\!`,
+)
+
+// EscapeMarkdown escapes every character MarkdownV2 reserves outside of
+// entities
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Telegram only understands these four entities in HTML mode
+var htmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// EscapeHTML escapes text for the HTML parse mode
+func EscapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
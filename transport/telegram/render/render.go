@@ -0,0 +1,105 @@
+// Package render builds the bot's screens from text/template templates.
+//
+// A template's parse mode comes from its file name: name.md.tmpl is sent as
+// MarkdownV2, name.html.tmpl as HTML and name.txt.tmpl as plain text. Inside
+// a template esc escapes a value for that mode, t and n look up the user's
+// message catalog. MarkdownV2 catalog entries are stored escaped, so t leaves
+// them as they are and only the arguments need esc; in HTML and plain
+// templates t escapes the whole message.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/go-telegram/bot/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Message is a rendered screen ready to be sent
+type Message struct {
+	Text      string
+	ParseMode models.ParseMode
+}
+
+type screen struct {
+	mode   models.ParseMode
+	escape func(string) string
+	tmpl   *template.Template
+}
+
+var screens = mustParse(templateFS)
+
+// Render executes the screen's template with data in the language of l
+func Render(l *i18n.Localizer, name string, data any) (Message, error) {
+	s, ok := screens[name]
+	if !ok {
+		return Message{}, fmt.Errorf("render: unknown screen %q", name)
+	}
+	tmpl, err := s.tmpl.Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"t": func(key string, args ...any) string {
+			if s.mode == models.ParseModeMarkdown {
+				return l.T(key, args...)
+			}
+			return s.escape(l.T(key, args...))
+		},
+		"n": func(key string, n int, args ...any) string {
+			if s.mode == models.ParseModeMarkdown {
+				return l.N(key, n, args...)
+			}
+			return s.escape(l.N(key, n, args...))
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+	return Message{Text: buf.String(), ParseMode: s.mode}, nil
+}
+
+func mustParse(fsys fs.FS) map[string]*screen {
+	files, err := fs.Glob(fsys, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	parsed := make(map[string]*screen, len(files))
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".tmpl")
+		ext := path.Ext(base)
+		s := &screen{}
+		switch ext {
+		case ".md":
+			s.mode, s.escape = models.ParseModeMarkdown, EscapeMarkdown
+		case ".html":
+			s.mode, s.escape = models.ParseModeHTML, EscapeHTML
+		case ".txt":
+			s.escape = func(s string) string { return s }
+		default:
+			panic(fmt.Sprintf("render: %s has no parse mode extension", file))
+		}
+		name := strings.TrimSuffix(base, ext)
+
+		// t and n are bound to a localizer on every Render
+		funcs := template.FuncMap{
+			"esc": s.escape,
+			"t":   func(string, ...any) string { return "" },
+			"n":   func(string, int, ...any) string { return "" },
+		}
+		s.tmpl = template.Must(template.New(path.Base(file)).Funcs(funcs).ParseFS(fsys, file))
+		parsed[name] = s
+	}
+	return parsed
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden with the current output")
+
+// reserved holds every character MarkdownV2 or HTML treats specially
+const reserved = `_*[]().!<>&"`
+
+func TestScreensGolden(t *testing.T) {
+	items := []ScheduleItem{
+		{Num: 1, TrainID: "6001_A", Title: `Экспресс "Ласточка" <№1> & *fast*_[a](b).!`, Departure: "01.02.2026 07:15", Arrival: "08:40", Duration: "1 ч 25 мин"},
+		{Num: 2, TrainID: "6003", Title: "Москва — Тула " + reserved, Departure: "01.02.2026 09:05", Arrival: "10:30", Duration: "1 ч 25 мин"},
+	}
+	schedule := ScheduleData{From: "Сокол_*[1]", To: `(Ясенево)! <A&B> "x"`, Items: items}
+	detailed := schedule
+	detailed.Details = true
+
+	details := EscapeMarkdown("Москва (Курская) → Тула-1. *Ласточка* " + reserved)
+
+	cases := []struct {
+		name   string
+		screen string
+		data   any
+	}{
+		{"welcome", Welcome, nil},
+		{"help", Help, nil},
+		{"schedule", Schedule, schedule},
+		{"schedule_details", Schedule, detailed},
+		{"trip_confirmed", TripConfirmed, TripConfirmedData{Details: details, LeadMinutes: 21}},
+		{"trip_already_booked", TripConfirmed, TripConfirmedData{Details: details, LeadMinutes: 5, AlreadyBooked: true}},
+		{"reminder", Reminder, ReminderData{Text: "Поезд в 07:15 " + reserved}},
+		{"reminder_group", Reminder, ReminderData{Text: "Train at 07:15 " + reserved, MentionID: 42, MentionName: `A&B <x> "y"_*`}},
+		{"error", Error, ErrorData{Text: `Файл "books.csv" > 1 MB ` + reserved}},
+		{"recoverable_error", RecoverableError, ErrorData{Text: "No trains " + reserved}},
+	}
+
+	for _, lang := range []string{"ru", "en"} {
+		l := i18n.For(lang)
+		for _, tc := range cases {
+			t.Run(tc.name+"/"+lang, func(t *testing.T) {
+				msg, err := Render(l, tc.screen, tc.data)
+				if err != nil {
+					t.Fatalf("render: %v", err)
+				}
+				got := "parse_mode: " + string(msg.ParseMode) + "\n\n" + msg.Text + "\n"
+
+				path := filepath.Join("testdata", tc.name+"."+lang+".golden")
+				if *update {
+					if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if got != string(want) {
+					t.Errorf("%s differs from the golden file\n--- got\n%s\n--- want\n%s", path, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestUnknownScreen(t *testing.T) {
+	if _, err := Render(i18n.For("ru"), "missing", nil); err == nil {
+		t.Fatal("expected an error for an unknown screen")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := EscapeMarkdown(`a\b_*[]()~`+"`"+`>#+-=|{}.!`), `a\\b\_\*\[\]\(\)\~\`+"`"+`\>\#\+\-\=\|\{\}\.\!`; got != want {
+		t.Errorf("EscapeMarkdown = %q, want %q", got, want)
+	}
+	if got, want := EscapeHTML(`<a href="x">&</a>`), `&lt;a href=&quot;x&quot;&gt;&amp;&lt;/a&gt;`; got != want {
+		t.Errorf("EscapeHTML = %q, want %q", got, want)
+	}
+}
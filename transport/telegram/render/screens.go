@@ -0,0 +1,51 @@
+package render
+
+// Screen names, one per template in templates/
+const (
+	Welcome          = "welcome"
+	Help             = "help"
+	Schedule         = "schedule"
+	TripConfirmed    = "trip_confirmed"
+	Reminder         = "reminder"
+	Error            = "error"
+	RecoverableError = "recoverable_error"
+)
+
+// ScheduleData lists the trains of a route; times and durations are already
+// formatted in the user's time zone
+type ScheduleData struct {
+	From    string
+	To      string
+	Details bool
+	Items   []ScheduleItem
+}
+
+type ScheduleItem struct {
+	Num       int
+	TrainID   string
+	Title     string
+	Departure string
+	Arrival   string
+	Duration  string
+}
+
+// TripConfirmedData is shown after booking. Details is MarkdownV2 that is
+// already escaped.
+type TripConfirmedData struct {
+	Details       string
+	LeadMinutes   int
+	AlreadyBooked bool
+}
+
+// ReminderData is a reminder text. Mention* are set when it goes to a group
+// chat, so the traveller it is meant for gets notified.
+type ReminderData struct {
+	Text        string
+	MentionID   int64
+	MentionName string
+}
+
+// ErrorData carries a translated error text, never err.Error()
+type ErrorData struct {
+	Text string
+}
@@ -0,0 +1,447 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/utils"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+var reminderLeadOptions = []int{10, 15, 30, 45, 60, 90}
+
+var timezoneOptions = []string{
+	"Europe/Kaliningrad",
+	"Europe/Moscow",
+	"Europe/Samara",
+	"Asia/Yekaterinburg",
+	"Asia/Omsk",
+	"Asia/Novosibirsk",
+	"Asia/Krasnoyarsk",
+	"Asia/Irkutsk",
+	"Asia/Vladivostok",
+}
+
+// errUnknownSetting rejects a field that is not in the settings menu
+var errUnknownSetting = domain.NewError("settings.unknown", "unknown setting")
+
+// quietHoursOptions are "start-end" presets, hours in the user's time zone
+var quietHoursOptions = []string{"22-7", "23-7", "23-8", "0-8"}
+
+var languageNames = map[string]string{
+	"ru": "Русский",
+	"en": "English",
+}
+
+// loadSettings returns the user's settings or defaults if they can't be loaded
+func (b *Bot) loadSettings(ctx context.Context, telegramID int64) *domain.UserSettings {
+	user, err := b.userUC.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return domain.DefaultUserSettings(0)
+	}
+	st, err := b.settingsUC.Get(ctx, user.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("load settings failed", logging.Err(err))
+		return domain.DefaultUserSettings(user.ID)
+	}
+	return st
+}
+
+// localizer returns the catalog in the user's language; senders who haven't
+// registered yet get the language of their Telegram client
+func (b *Bot) localizer(ctx context.Context, from *models.User) *i18n.Localizer {
+	st := b.loadSettings(ctx, from.ID)
+	if st.UserID == 0 {
+		if lang := i18n.Match(from.LanguageCode); lang != "" {
+			return i18n.For(lang)
+		}
+	}
+	return i18n.For(st.Language)
+}
+
+func (b *Bot) SettingsHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	user, err := b.ensureUser(ctx, update.Message.From, update.Message.Chat)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	st, err := b.settingsUC.Get(ctx, user.ID)
+	if err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	l := i18n.For(st.Language)
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        b.buildSettingsText(ctx, l, st),
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buildSettingsKeyboard(l, st)},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// LanguageHandler offers the bot languages; the choice is saved like any
+// other setting
+func (b *Bot) LanguageHandler(ctx context.Context, botClient *bot.Bot, update *models.Update) {
+	if _, err := b.ensureUser(ctx, update.Message.From, update.Message.Chat); err != nil {
+		b.sendErrorMessage(ctx, update, err)
+		return
+	}
+
+	text, keyboard := buildSettingsSubmenu(b.localizer(ctx, update.Message.From), "lang")
+	_, err := b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+// handleSettingsMenu opens the settings menu or one of its submenus
+func (b *Bot) handleSettingsMenu(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	if callbackQuery.Message.Message == nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+		return
+	}
+
+	l := b.localizer(ctx, &callbackQuery.From)
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+	st, err := b.settingsUC.Get(ctx, user.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("settings.load_failed"))
+		return
+	}
+
+	text := b.buildSettingsText(ctx, l, st)
+	keyboard := buildSettingsKeyboard(l, st)
+	if len(params) > 0 {
+		text, keyboard = buildSettingsSubmenu(l, params[0])
+	}
+
+	b.editSettingsMessage(ctx, botClient, callbackQuery.Message.Message, text, keyboard)
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// handleSettingsValue stores a value chosen in a settings submenu
+// Format: sv:field:value
+func (b *Bot) handleSettingsValue(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 2 || callbackQuery.Message.Message == nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
+		return
+	}
+
+	apply, err := settingsMutation(params[0], strings.Join(params[1:], ":"))
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	st, err := b.settingsUC.Update(ctx, user.ID, apply)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	}
+
+	// A language change applies right away
+	l = i18n.For(st.Language)
+	b.editSettingsMessage(ctx, botClient, callbackQuery.Message.Message, b.buildSettingsText(ctx, l, st), buildSettingsKeyboard(l, st))
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.saved"))
+}
+
+// handleStationSettingStart lets the user type a home or work station that
+// is not among the popular ones
+// Format: si:field
+func (b *Bot) handleStationSettingStart(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, params []string) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) == 0 || callbackQuery.Message.Message == nil {
+		b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.bad_params"))
+		return
+	}
+
+	var text string
+	switch params[0] {
+	case "home":
+		session.State, text = StateWaitingHome, l.T("settings.input_home")
+	case "work":
+		session.State, text = StateWaitingWork, l.T("settings.input_work")
+	default:
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, errUnknownSetting))
+		return
+	}
+	session.StateHistory = []UserState{session.State}
+
+	b.reply(ctx, callbackQuery.Message.Message.Chat.ID, text)
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// handleStationSettingInput resolves a typed home or work station like the
+// trip dialog does, offering buttons when the name is ambiguous
+func (b *Bot) handleStationSettingInput(ctx context.Context, update *models.Update, session *UserSession, l *i18n.Localizer, text string) {
+	selecting := StateSelectingHome
+	if session.State == StateWaitingWork {
+		selecting = StateSelectingWork
+	}
+	station, ok := b.resolveTypedStation(ctx, update.Message.Chat.ID, session, l, text, selecting, false)
+	if !ok {
+		return
+	}
+	session.State = selecting
+	b.saveStationSetting(ctx, update.Message.Chat.ID, update.Message.From, session, station)
+}
+
+// saveStationSetting stores the station picked in a selecting state as the
+// home or work station and shows the settings again
+func (b *Bot) saveStationSetting(ctx context.Context, chatID int64, from *models.User, session *UserSession, station utils.StationOption) {
+	home := session.State == StateSelectingHome
+	session.State = StateNone
+	session.StateHistory = nil
+	session.StationMatches = nil
+
+	l := b.localizer(ctx, from)
+	user, err := b.userUC.GetUserByTelegramID(ctx, from.ID)
+	if err != nil {
+		b.reply(ctx, chatID, l.T("common.user_error"))
+		return
+	}
+	code := station.Code
+	st, err := b.settingsUC.Update(ctx, user.ID, func(st *domain.UserSettings) {
+		if home {
+			st.HomeStation = &code
+		} else {
+			st.WorkStation = &code
+		}
+	})
+	if err != nil {
+		b.reply(ctx, chatID, l.T("common.error", errorText(l, err)))
+		return
+	}
+
+	_, err = b.sendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        b.buildSettingsText(ctx, l, st),
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buildSettingsKeyboard(l, st)},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("send message failed", logging.Err(err))
+	}
+}
+
+func (b *Bot) editSettingsMessage(ctx context.Context, botClient *bot.Bot, msg *models.Message, text string, keyboard [][]models.InlineKeyboardButton) {
+	_, err := b.editMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("edit settings message failed", logging.Err(err))
+	}
+}
+
+// settingsMutation converts callback field/value into a settings update
+func settingsMutation(field, value string) (func(st *domain.UserSettings), error) {
+	switch field {
+	case "lead":
+		minutes, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, domain.ErrInvalidReminderLead
+		}
+		return func(st *domain.UserSettings) { st.ReminderLeadMinutes = minutes }, nil
+
+	case "lang":
+		return func(st *domain.UserSettings) { st.Language = value }, nil
+
+	case "tz":
+		return func(st *domain.UserSettings) { st.Timezone = value }, nil
+
+	case "home", "work":
+		var code *string
+		if value != "-" {
+			idx, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, domain.ErrStationNotFound
+			}
+			station, found := utils.GetStationByIndex(idx)
+			if !found {
+				return nil, domain.ErrStationNotFound
+			}
+			code = &station.Code
+		}
+		if field == "home" {
+			return func(st *domain.UserSettings) { st.HomeStation = code }, nil
+		}
+		return func(st *domain.UserSettings) { st.WorkStation = code }, nil
+
+	case "quiet":
+		if value == "off" {
+			return func(st *domain.UserSettings) {
+				st.QuietHoursStart = nil
+				st.QuietHoursEnd = nil
+			}, nil
+		}
+		start, end, ok := strings.Cut(value, "-")
+		if !ok {
+			return nil, domain.ErrInvalidQuietHours
+		}
+		startHour, err1 := strconv.Atoi(start)
+		endHour, err2 := strconv.Atoi(end)
+		if err1 != nil || err2 != nil {
+			return nil, domain.ErrInvalidQuietHours
+		}
+		return func(st *domain.UserSettings) {
+			st.QuietHoursStart = &startHour
+			st.QuietHoursEnd = &endHour
+		}, nil
+
+	case "details":
+		return func(st *domain.UserSettings) { st.ShowDetails = !st.ShowDetails }, nil
+	}
+
+	return nil, errUnknownSetting
+}
+
+func (b *Bot) buildSettingsText(ctx context.Context, l *i18n.Localizer, st *domain.UserSettings) string {
+	var sb strings.Builder
+	sb.WriteString(l.T("settings.title"))
+	sb.WriteString(l.T("settings.lead", st.ReminderLeadMinutes) + "\n")
+	sb.WriteString(l.T("settings.language", languageNames[st.Language]) + "\n")
+	sb.WriteString(l.T("settings.timezone", st.Timezone) + "\n")
+	sb.WriteString(l.T("settings.home", b.stationSettingName(ctx, l, st.HomeStation)) + "\n")
+	sb.WriteString(l.T("settings.work", b.stationSettingName(ctx, l, st.WorkStation)) + "\n")
+	sb.WriteString(l.T("settings.quiet", quietHoursName(l, st)) + "\n")
+	sb.WriteString(l.T("settings.details", onOff(l, st.ShowDetails)) + "\n")
+	return sb.String()
+}
+
+func buildSettingsKeyboard(l *i18n.Localizer, st *domain.UserSettings) [][]models.InlineKeyboardButton {
+	return [][]models.InlineKeyboardButton{
+		{{Text: l.T("settings.button.lead", st.ReminderLeadMinutes), CallbackData: "st:lead"}},
+		{{Text: l.T("settings.language", languageNames[st.Language]), CallbackData: "st:lang"}},
+		{{Text: l.T("settings.timezone", st.Timezone), CallbackData: "st:tz"}},
+		{
+			{Text: l.T("settings.button.home"), CallbackData: "st:home"},
+			{Text: l.T("settings.button.work"), CallbackData: "st:work"},
+		},
+		{{Text: l.T("settings.quiet", quietHoursName(l, st)), CallbackData: "st:quiet"}},
+		{{Text: l.T("settings.button.details", onOff(l, st.ShowDetails)), CallbackData: "sv:details:toggle"}},
+	}
+}
+
+// buildSettingsSubmenu builds the list of choices for a single setting
+func buildSettingsSubmenu(l *i18n.Localizer, field string) (string, [][]models.InlineKeyboardButton) {
+	buttons := [][]models.InlineKeyboardButton{}
+	var text string
+
+	switch field {
+	case "lead":
+		text = l.T("settings.lead_prompt")
+		row := []models.InlineKeyboardButton{}
+		for _, m := range reminderLeadOptions {
+			row = append(row, models.InlineKeyboardButton{
+				Text:         strconv.Itoa(m),
+				CallbackData: fmt.Sprintf("sv:lead:%d", m),
+			})
+		}
+		buttons = append(buttons, row)
+
+	case "lang":
+		text = l.T("settings.lang_prompt")
+		for _, lang := range domain.SupportedLanguages {
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: languageNames[lang], CallbackData: "sv:lang:" + lang},
+			})
+		}
+
+	case "tz":
+		text = l.T("settings.tz_prompt")
+		for _, tz := range timezoneOptions {
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: tz, CallbackData: "sv:tz:" + tz},
+			})
+		}
+
+	case "home", "work":
+		if field == "home" {
+			text = l.T("settings.home_prompt")
+		} else {
+			text = l.T("settings.work_prompt")
+		}
+		for i := 0; i < 7 && i < len(utils.PopularStations); i++ {
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: "📍 " + utils.PopularStations[i].DisplayName, CallbackData: fmt.Sprintf("sv:%s:%d", field, i)},
+			})
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: l.T("button.type_station"), CallbackData: "si:" + field},
+			{Text: l.T("settings.reset"), CallbackData: fmt.Sprintf("sv:%s:-", field)},
+		})
+
+	case "quiet":
+		text = l.T("settings.quiet_prompt")
+		for _, opt := range quietHoursOptions {
+			start, end, _ := strings.Cut(opt, "-")
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: fmt.Sprintf("%s:00 – %s:00", start, end), CallbackData: "sv:quiet:" + opt},
+			})
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: l.T("settings.quiet_off"), CallbackData: "sv:quiet:off"},
+		})
+
+	default:
+		text = l.T("settings.unknown")
+	}
+
+	buttons = append(buttons, []models.InlineKeyboardButton{
+		{Text: l.T("button.back"), CallbackData: "st"},
+	})
+	return text, buttons
+}
+
+// stationSettingName names a home or work station, which may also come from
+// the imported directory
+func (b *Bot) stationSettingName(ctx context.Context, l *i18n.Localizer, code *string) string {
+	if code == nil {
+		return l.T("settings.not_set")
+	}
+	if station, found := b.tripUC.FindStation(ctx, *code); found {
+		return station.DisplayName
+	}
+	return *code
+}
+
+func quietHoursName(l *i18n.Localizer, st *domain.UserSettings) string {
+	if !st.HasQuietHours() {
+		return l.T("settings.off")
+	}
+	return fmt.Sprintf("%d:00 – %d:00", *st.QuietHoursStart, *st.QuietHoursEnd)
+}
+
+func onOff(l *i18n.Localizer, v bool) string {
+	if v {
+		return l.T("settings.on")
+	}
+	return l.T("settings.off")
+}
@@ -0,0 +1,229 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/i18n"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// editDateLayout encodes the date in "td:<trip>:<date>" callbacks
+const editDateLayout = "20060102"
+
+// editDays is how many days from today the date picker offers
+const editDays = 3
+
+// handleTripEdit starts changing a booked trip by asking for the new date
+func (b *Bot) handleTripEdit(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	trip, ok := b.callbackTrip(ctx, botClient, callbackQuery, params)
+	if !ok {
+		return
+	}
+	settings := b.loadSettings(ctx, callbackQuery.From.ID)
+	l, loc := i18n.For(settings.Language), settings.Location()
+	if !trip.DepartureTime.After(time.Now()) {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, domain.ErrTripDeparted))
+		return
+	}
+
+	session := b.getSession(callbackQuery.From.ID)
+	b.resetTripEdit(ctx, session, trip)
+
+	text := l.T("edit.title", trip.ID) +
+		tripDetails(l, trip, session.FromName, session.ToName, loc, "") +
+		l.T("edit.pick_date")
+
+	msg := callbackQuery.Message.Message
+	_, err := b.editMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: editDateKeyboard(l, trip, time.Now().In(loc))},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("edit trip date picker failed", logging.Err(err))
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, "")
+}
+
+// handleTripEditDate shows trains of the trip's route for the picked date
+func (b *Bot) handleTripEditDate(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) {
+	settings := b.loadSettings(ctx, callbackQuery.From.ID)
+	l, loc := i18n.For(settings.Language), settings.Location()
+	if len(params) < 2 {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
+		return
+	}
+	trip, ok := b.callbackTrip(ctx, botClient, callbackQuery, params)
+	if !ok {
+		return
+	}
+
+	day, err := time.ParseInLocation(editDateLayout, params[1], loc)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_format"))
+		return
+	}
+	start := day
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+
+	session := b.getSession(callbackQuery.From.ID)
+	b.resetTripEdit(ctx, session, trip)
+	session.Date = start
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("common.searching"))
+
+	chatID := callbackQuery.Message.Message.Chat.ID
+	retry := []models.InlineKeyboardButton{
+		{Text: l.T("button.other_date"), CallbackData: fmt.Sprintf("te:%d", trip.ID)},
+		{Text: l.T("button.cancel"), CallbackData: "x"},
+	}
+	options, err := b.tripUC.Search(ctx, trip.From, trip.To, start)
+	if err != nil {
+		b.sendRecoverableError(ctx, botClient, chatID, l, l.T("trip.search_failed", errorText(l, err)), retry)
+		return
+	}
+	if len(options) == 0 {
+		b.sendRecoverableError(ctx, botClient, chatID, l, l.T("trip.no_trains"), retry)
+		return
+	}
+
+	session.Schedule = options
+	b.sendScheduleMessage(ctx, botClient, chatID, session)
+}
+
+// handleTripChange moves the trip being edited to the selected train
+func (b *Bot) handleTripChange(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, session *UserSession, opt *domain.Schedule) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return
+	}
+
+	trip, err := b.tripUC.ChangeTrain(ctx, user.ID, session.EditTripID, opt, time.Now())
+	switch {
+	case errors.Is(err, domain.ErrTripAlreadyBooked), errors.Is(err, domain.ErrTripDeparted), errors.Is(err, domain.ErrTripNotFound),
+		errors.Is(err, domain.ErrTripRouteMismatch):
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return
+	case err != nil:
+		logging.FromContext(ctx).Error("change trip failed", "trip_id", session.EditTripID, logging.Err(err))
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("edit.failed"))
+		return
+	}
+
+	b.clearSession(callbackQuery.From.ID)
+
+	settings := b.loadSettings(ctx, callbackQuery.From.ID)
+	details := tripDetails(l, trip, b.stationLabel(ctx, trip.From, trip.FromName), b.stationLabel(ctx, trip.To, trip.ToName), settings.Location(), "")
+	text := l.T("edit.done", trip.ID, details, l.N("unit.minutes", settings.ReminderLeadMinutes))
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: l.T("button.open_trip", trip.ID), CallbackData: fmt.Sprintf("tv:%d", trip.ID)}},
+	}}
+
+	if msg := callbackQuery.Message.Message; msg != nil {
+		_, err = b.editMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        text,
+			ParseMode:   models.ParseModeMarkdown,
+			ReplyMarkup: keyboard,
+		})
+	}
+	if callbackQuery.Message.Message == nil || err != nil {
+		_, _ = b.sendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      callbackQuery.From.ID,
+			Text:        text,
+			ParseMode:   models.ParseModeMarkdown,
+			ReplyMarkup: keyboard,
+		})
+	}
+	b.answerCallback(ctx, botClient, callbackQuery.ID, l.T("edit.done_answer"))
+}
+
+// callbackTrip loads the user's trip from the first callback parameter,
+// answering the callback with an error when it fails
+func (b *Bot) callbackTrip(ctx context.Context, botClient *bot.Bot, callbackQuery *models.CallbackQuery, params []string) (*domain.Trip, bool) {
+	l := b.localizer(ctx, &callbackQuery.From)
+	if len(params) < 1 || callbackQuery.Message.Message == nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_params"))
+		return nil, false
+	}
+	tripID, err := strconv.ParseInt(params[0], 10, 64)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.bad_format"))
+		return nil, false
+	}
+
+	user, err := b.userUC.GetUserByTelegramID(ctx, callbackQuery.From.ID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, l.T("common.user_error"))
+		return nil, false
+	}
+	trip, err := b.tripUC.GetTrip(ctx, user.ID, tripID)
+	if err != nil {
+		sendCallbackError(ctx, botClient, callbackQuery, errorText(l, err))
+		return nil, false
+	}
+	return trip, true
+}
+
+// resetTripEdit points the session at the trip's route, dropping any booking
+// in progress
+func (b *Bot) resetTripEdit(ctx context.Context, session *UserSession, trip *domain.Trip) {
+	session.State = StateShowingSchedule
+	session.StateHistory = []UserState{StateShowingSchedule}
+	session.From = trip.From
+	session.FromName = b.stationLabel(ctx, trip.From, trip.FromName)
+	session.To = trip.To
+	session.ToName = b.stationLabel(ctx, trip.To, trip.ToName)
+	session.Date = time.Now()
+	session.Schedule = nil
+	session.SchedulePage = 0
+	session.EditTripID = trip.ID
+}
+
+// editDateKeyboard offers the next few days and the trip's current date
+func editDateKeyboard(l *i18n.Localizer, trip *domain.Trip, now time.Time) [][]models.InlineKeyboardButton {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dep := trip.DepartureTime.In(now.Location())
+	tripDay := time.Date(dep.Year(), dep.Month(), dep.Day(), 0, 0, 0, 0, now.Location())
+
+	var row []models.InlineKeyboardButton
+	for i := range editDays {
+		day := today.AddDate(0, 0, i)
+		label := day.Format("02.01")
+		switch i {
+		case 0:
+			label = l.T("edit.today")
+		case 1:
+			label = l.T("edit.tomorrow")
+		}
+		if day.Equal(tripDay) {
+			label = "📌 " + label
+		}
+		row = append(row, models.InlineKeyboardButton{
+			Text:         label,
+			CallbackData: fmt.Sprintf("td:%d:%s", trip.ID, day.Format(editDateLayout)),
+		})
+	}
+
+	keyboard := [][]models.InlineKeyboardButton{row}
+	if tripDay.After(today.AddDate(0, 0, editDays-1)) {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{{
+			Text:         "📌 " + tripDay.Format("02.01.2006"),
+			CallbackData: fmt.Sprintf("td:%d:%s", trip.ID, tripDay.Format(editDateLayout)),
+		}})
+	}
+	return append(keyboard, []models.InlineKeyboardButton{{Text: l.T("button.cancel"), CallbackData: "x"}})
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-telegram/bot"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// StartWebhook processes updates delivered to WebhookHandler until ctx is
+// done. It is the webhook counterpart of Start.
+func (b *Bot) StartWebhook(ctx context.Context) {
+	if b.sessionTTL > 0 {
+		go b.evictIdleSessions(ctx)
+	}
+	b.webhookRunning.Store(true)
+	defer b.webhookRunning.Store(false)
+	b.client.StartWebhook(ctx)
+}
+
+// WebhookHandler accepts updates from Telegram. Requests without the
+// expected secret token are rejected, and while the bot is not processing
+// updates it answers 503 so Telegram retries instead of losing them.
+func (b *Bot) WebhookHandler(secret string) http.Handler {
+	updates := b.client.WebhookHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			slog.Warn("webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !b.webhookRunning.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		updates(w, r)
+	})
+}
+
+// SetWebhook registers url with Telegram; updates are then pushed to it
+// instead of being fetched with getUpdates
+func (b *Bot) SetWebhook(ctx context.Context, url, secret string) error {
+	ok, err := b.client.SetWebhook(ctx, &bot.SetWebhookParams{
+		URL:         url,
+		SecretToken: secret,
+	})
+	if err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("set webhook: telegram refused %s", url)
+	}
+	b.webhookRegistered.Store(true)
+	return nil
+}
+
+// DeleteWebhook switches Telegram back to getUpdates. Pending updates are
+// kept so that the next instance picks them up.
+func (b *Bot) DeleteWebhook(ctx context.Context) error {
+	if _, err := b.client.DeleteWebhook(ctx, &bot.DeleteWebhookParams{}); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	b.webhookRegistered.Store(false)
+	return nil
+}
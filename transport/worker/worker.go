@@ -2,130 +2,422 @@ package worker
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/X1ag/TravelScheduler/internal/domain"
+	"github.com/X1ag/TravelScheduler/internal/logging"
+	"github.com/X1ag/TravelScheduler/internal/metrics"
 	"github.com/X1ag/TravelScheduler/internal/repository/postgres"
 	"github.com/X1ag/TravelScheduler/internal/usecase"
 	"github.com/X1ag/TravelScheduler/transport/telegram"
 )
 
+const (
+	// claimBatchSize is how many reminders a poller leases at once
+	claimBatchSize = 50
+	// claimLease is how long a claimed reminder stays invisible to other
+	// pollers; after that it is considered abandoned and claimed again
+	claimLease = 2 * time.Minute
+	// releaseTimeout bounds returning unhandled claims on shutdown
+	releaseTimeout = 5 * time.Second
+)
+
+var (
+	// errLeaseExpired fails reminders whose handling kept outliving the lease
+	errLeaseExpired = errors.New("lease expired on every attempt")
+	// errUserNotFound fails reminders whose user is gone
+	errUserNotFound = errors.New("reminder user not found")
+)
+
+// Config tunes the reminder worker
+type Config struct {
+	// PollInterval bounds the sleep in case a notification is lost
+	PollInterval time.Duration
+	// Concurrency is how many reminders of a batch are sent in parallel
+	Concurrency int
+}
+
 type Worker struct {
 	tripUC      *usecase.TripUsecase
 	bookUC      *usecase.BookUsecase
 	userUC      *usecase.UserUsecase
+	reminderUC  *usecase.ReminderUsecase
 	reminderRepo *postgres.ReminderRepository
+	listener    *postgres.ReminderListener
 	bot         *telegram.Bot
-	mu          sync.Mutex
-}
+	cfg         Config
+	log         *slog.Logger
 
-func NewWorker(tripUC *usecase.TripUsecase, bookUC *usecase.BookUsecase, userUC *usecase.UserUsecase, reminderRepo *postgres.ReminderRepository, bot *telegram.Bot) *Worker {
-	// Настроим формат логов (включая микросекунды и короткое имя файла)
-	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
-	log.Printf("INFO: NewWorker created")
+	lastTick atomic.Int64 // unix nanos of the last successful claim
 
+	stopPolling  context.CancelFunc // stops claiming new reminders
+	stopHandlers context.CancelFunc // aborts in-flight sends once the drain deadline passed
+	pollers      sync.WaitGroup
+
+	claimedMu sync.Mutex
+	claimed   map[int64]*domain.Reminder // leased but not handled yet
+}
+
+func NewWorker(tripUC *usecase.TripUsecase, bookUC *usecase.BookUsecase, userUC *usecase.UserUsecase, reminderUC *usecase.ReminderUsecase, reminderRepo *postgres.ReminderRepository, listener *postgres.ReminderListener, bot *telegram.Bot, cfg Config) *Worker {
 	return &Worker{
 		bot:          bot,
 		tripUC:       tripUC,
 		reminderRepo: reminderRepo,
+		listener:     listener,
 		bookUC:       bookUC,
 		userUC:       userUC,
+		reminderUC:   reminderUC,
+		cfg:          cfg,
+		log:          slog.With("component", "worker"),
+		claimed:      make(map[int64]*domain.Reminder),
 	}
 }
 
+// StartPolling starts count schedulers. Each one sleeps until the next
+// reminder is due and is woken early by LISTEN/NOTIFY when an earlier
+// reminder is scheduled. Without a listener it falls back to plain polling.
 func (w *Worker) StartPolling(ctx context.Context, count int) {
-	log.Printf("INFO: StartPolling called, starting %d pollers", count)
+	w.log.Info("starting pollers", "count", count)
+
+	// In-flight sends must survive the shutdown signal, so handlers get a
+	// context that is only canceled by Stop after the drain deadline
+	handlerCtx, stopHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	ctx, stopPolling := context.WithCancel(ctx)
+	w.stopPolling = stopPolling
+	w.stopHandlers = stopHandlers
+
+	wakeups := make([]chan time.Time, count)
+	for i := range wakeups {
+		wakeups[i] = make(chan time.Time, 1)
+	}
+
+	if w.listener != nil {
+		go w.listener.Run(ctx, func(triggerAt time.Time) {
+			for _, wake := range wakeups {
+				select {
+				case wake <- triggerAt:
+				default:
+					// poller already has a pending wakeup
+				}
+			}
+		})
+	}
+
 	for i := 0; i < count; i++ {
+		w.pollers.Add(1)
 		go func(idx int) {
-			log.Printf("INFO: starting poller goroutine #%d", idx)
-			w.checkPendings(ctx)
-			log.Printf("INFO: poller goroutine #%d exited", idx)
+			defer w.pollers.Done()
+			log := w.log.With("poller", idx)
+			log.Debug("poller started")
+			w.schedule(ctx, handlerCtx, wakeups[idx], log)
+			log.Debug("poller exited")
 		}(i)
 	}
 }
 
-func (w *Worker) checkPendings(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+// Stop stops claiming reminders and waits for in-flight handlers to finish.
+// If ctx expires first, in-flight sends are canceled. Reminders that were
+// claimed but not handled are released back to pending, so the next worker
+// doesn't wait for their lease to expire.
+func (w *Worker) Stop(ctx context.Context) error {
+	if w.stopPolling == nil {
+		return nil
+	}
+	w.stopPolling()
+
+	done := make(chan struct{})
+	go func() {
+		w.pollers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.log.Info("worker drained")
+		w.stopHandlers()
+		w.releaseClaims(ctx)
+		return nil
+	case <-ctx.Done():
+		w.log.Error("worker drain deadline exceeded, canceling in-flight reminders")
+		w.stopHandlers()
+		<-done
+		w.releaseClaims(ctx)
+		return ctx.Err()
+	}
+}
+
+// releaseClaims returns reminders left unhandled by the shutdown to pending.
+// It runs after every handler has returned, possibly past ctx's deadline.
+func (w *Worker) releaseClaims(ctx context.Context) {
+	w.claimedMu.Lock()
+	defer w.claimedMu.Unlock()
+	if len(w.claimed) == 0 {
+		return
+	}
+	reminders := make([]*domain.Reminder, 0, len(w.claimed))
+	for _, rem := range w.claimed {
+		reminders = append(reminders, rem)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+	released, err := w.reminderRepo.Release(ctx, reminders)
+	if err != nil {
+		w.log.Error("release claimed reminders failed, they wait for the lease to expire", "count", len(reminders), logging.Err(err))
+		return
+	}
+	w.log.Info("released unhandled reminders", "claimed", len(reminders), "released", released)
+	clear(w.claimed)
+}
+
+// track records claimed reminders until their handler is done with them
+func (w *Worker) track(reminders []*domain.Reminder) {
+	w.claimedMu.Lock()
+	defer w.claimedMu.Unlock()
+	for _, rem := range reminders {
+		w.claimed[rem.ID] = rem
+	}
+}
+
+func (w *Worker) untrack(id int64) {
+	w.claimedMu.Lock()
+	defer w.claimedMu.Unlock()
+	delete(w.claimed, id)
+}
+
+func (w *Worker) schedule(ctx, handlerCtx context.Context, wake <-chan time.Time, log *slog.Logger) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
 
 	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("INFO: checkPendings received ctx.Done(), exiting")
+		w.processDue(ctx, handlerCtx, log)
+		if ctx.Err() != nil {
 			return
-		case tickTime := <-ticker.C:
-			log.Printf("INFO: tick at %s - checking pendings", tickTime.Format(time.RFC3339))
-
-			pendings, err := w.reminderRepo.GetPending(ctx, time.Now())
-			if err != nil {
-				log.Printf("ERROR: error getting pendings: %v", err)
-				// продолжим на следующем тике
-				continue
-			}
+		}
 
-			if len(pendings) == 0 {
-				log.Printf("INFO: no pending reminders found")
-				continue
-			}
+		next := w.nextWakeup(ctx, log)
+		log.Debug("next reminder check", "at", next)
+		timer.Reset(time.Until(next))
 
-			log.Printf("INFO: found %d pending reminders", len(pendings))
-
-			var wg sync.WaitGroup
-			sem := make(chan struct{}, 10) // ограничение конкурентности
-
-			for _, pending := range pendings {
-				wg.Add(1)
-				sem <- struct{}{}
-				p := pending // копируем для горутины
-				log.Printf("INFO: scheduling handler for reminder id=%d trip_id=%d user_id=%d trigger_at=%s",
-					p.ID, p.TripID, p.UserID, p.TriggerAt.String())
-
-				go func(rem *domain.Reminder) {
-					defer wg.Done()
-					defer func() { <-sem }()
-					if err := w.handlePending(ctx, rem); err != nil {
-						log.Printf("ERROR: error handling pending id=%d: %v", rem.ID, err)
-					} else {
-						log.Printf("INFO: successfully handled pending id=%d", rem.ID)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				break wait
+			case triggerAt := <-wake:
+				if triggerAt.Before(next) {
+					log.Debug("woken up by scheduled reminder", "trigger_at", triggerAt)
+					if !timer.Stop() {
+						<-timer.C
 					}
-				}(p)
+					break wait
+				}
 			}
-			wg.Wait()
-			log.Printf("INFO: finished processing %d pendings", len(pendings))
 		}
 	}
 }
 
+// LastTick returns when reminders were last claimed successfully, zero
+// before the first run
+func (w *Worker) LastTick() time.Time {
+	n := w.lastTick.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// MaxTickAge is how long LastTick may lag before the worker is considered
+// stuck: pollers wake at least every PollInterval, and a batch may take up
+// to the claim lease
+func (w *Worker) MaxTickAge() time.Duration {
+	return 2*w.cfg.PollInterval + claimLease
+}
+
+// nextWakeup returns when the earliest reminder is due, capped by the fallback interval
+func (w *Worker) nextWakeup(ctx context.Context, log *slog.Logger) time.Time {
+	fallback := time.Now().Add(w.cfg.PollInterval)
+
+	dueAt, err := w.reminderRepo.NextDueAt(ctx)
+	if err != nil {
+		log.Error("get next due reminder failed", logging.Err(err))
+		return fallback
+	}
+	if dueAt == nil || dueAt.After(fallback) {
+		return fallback
+	}
+	return *dueAt
+}
+
+// processDue claims and handles due reminders until none are left
+func (w *Worker) processDue(ctx, handlerCtx context.Context, log *slog.Logger) {
+	for ctx.Err() == nil {
+		pendings, err := w.reminderRepo.ClaimPending(ctx, time.Now(), claimBatchSize, claimLease)
+		if err != nil {
+			log.Error("claim reminders failed", logging.Err(err))
+			return
+		}
+		w.lastTick.Store(time.Now().UnixNano())
+
+		if len(pendings) == 0 {
+			return
+		}
+
+		log.Info("claimed reminders", "count", len(pendings))
+		w.track(pendings)
+		w.handleBatch(ctx, handlerCtx, pendings)
+
+		if len(pendings) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// handleBatch sends claimed reminders; ctx stops launching new handlers,
+// handlerCtx is passed to the handlers themselves
+func (w *Worker) handleBatch(ctx, handlerCtx context.Context, pendings []*domain.Reminder) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, w.cfg.Concurrency) // ограничение конкурентности
+
+	for _, pending := range pendings {
+		if ctx.Err() != nil {
+			// Shutting down: Stop releases the rest
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		p := pending // копируем для горутины
+
+		go func(rem *domain.Reminder) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ctx := logging.WithLogger(handlerCtx, w.log.With(
+				"reminder_id", rem.ID, "trip_id", rem.TripID, "user_id", rem.UserID))
+			err := w.handlePending(ctx, rem)
+			if err == nil || handlerCtx.Err() == nil {
+				// Canceled sends stay tracked for Stop to release
+				w.untrack(rem.ID)
+			}
+			switch {
+			case errors.Is(err, domain.ErrReminderLeaseLost):
+				// Another poller owns it now and will finish it
+				logging.FromContext(ctx).Warn("reminder lease lost before it was handled", "lease", rem.Lease())
+			case err != nil:
+				logging.FromContext(ctx).Error("handle reminder failed", logging.Err(err))
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
 func (w *Worker) handlePending(ctx context.Context, pending *domain.Reminder) error {
-	log.Printf("INFO: handlePending start id=%d trip_id=%d user_id=%d", pending.ID, pending.TripID, pending.UserID)
+	log := logging.FromContext(ctx)
+
+	if pending.Attempts >= usecase.MaxDeliveryAttempts {
+		// Reclaimed after expired leases until attempts ran out
+		metrics.ObserveReminderDelivery(metrics.ReminderFailed)
+		log.Error("reminder failed permanently", "attempts", pending.Attempts, logging.Err(errLeaseExpired))
+		return w.reminderUC.MarkFailed(ctx, pending, errLeaseExpired)
+	}
 
 	user, err := w.userUC.GetUserByID(ctx, pending.UserID)
-	if err != nil {
-		log.Printf("ERROR: error getting user for reminder id=%d user_id=%d: %v", pending.ID, pending.UserID, err)
-		return err
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return fmt.Errorf("get user: %w", err)
 	}
 	if user == nil {
-		log.Printf("ERROR: user not found for reminder id=%d user_id=%d", pending.ID, pending.UserID)
-		return nil 
+		// Nobody to send it to; failing it keeps it from being claimed again
+		metrics.ObserveReminderDelivery(metrics.ReminderFailed)
+		log.Error("reminder failed permanently", logging.Err(errUserNotFound))
+		return w.reminderUC.MarkFailed(ctx, pending, errUserNotFound)
+	}
+	chatID, group, err := w.reminderChat(ctx, user, pending)
+	if err != nil {
+		return fmt.Errorf("resolve chat: %w", err)
+	}
+	log = log.With("telegram_id", user.TelegramID, "chat_id", chatID)
+
+	delivery, err := w.reminderUC.PlanDelivery(ctx, pending, time.Now())
+	if err != nil {
+		return fmt.Errorf("plan delivery: %w", err)
+	}
+	if !delivery.DeferUntil.IsZero() {
+		log.Info("quiet hours, deferring reminder", "until", delivery.DeferUntil)
+		metrics.ObserveReminderDelivery(metrics.ReminderDeferred)
+		return w.reminderUC.Defer(ctx, pending, delivery.DeferUntil)
 	}
-	log.Printf("INFO: found user id=%d telegram_id=%d for reminder id=%d", user.ID, user.TelegramID, pending.ID)
 
 	sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	log.Printf("INFO: sending message for reminder id=%d to telegram_id=%d", pending.ID, user.TelegramID)
-	w.mu.Lock()
-	w.bot.SendMessage(sendCtx, user.TelegramID, pending.Message); 	
-	w.mu.Unlock()
-	log.Printf("INFO: message sent for reminder id=%d to telegram_id=%d", pending.ID, user.TelegramID)
+	err = w.bot.SendReminder(sendCtx, chatID, user, pending, delivery.Silent)
+	if err != nil {
+		return w.handleSendFailure(ctx, log, pending, user, group, err)
+	}
+	metrics.ObserveReminderSent(pending.TriggerAt, time.Now())
+
+	if err := w.reminderRepo.MarkAsSent(ctx, pending.ID, pending.Lease()); err != nil {
+		return fmt.Errorf("mark as sent: %w", err)
+	}
+	log.Info("reminder sent", "lag", time.Since(pending.TriggerAt).Round(time.Millisecond))
+
+	return nil
+}
+
+// reminderChat picks where a reminder goes: the user's private chat, or the
+// group the trip was booked in for companions who never wrote to the bot
+// directly. group reports the latter.
+func (w *Worker) reminderChat(ctx context.Context, user *domain.User, pending *domain.Reminder) (chatID int64, group bool, err error) {
+	if user.ChatID != 0 {
+		return user.ChatID, false, nil
+	}
+	if pending.TripID != 0 {
+		tr, err := w.tripUC.GetByID(ctx, pending.TripID)
+		if err != nil {
+			return 0, false, err
+		}
+		if tr.ChatID != 0 {
+			return tr.ChatID, true, nil
+		}
+	}
+	// In a private chat the chat ID is the user ID
+	return user.TelegramID, false, nil
+}
 
-	if err := w.reminderRepo.MarkAsSent(ctx, pending.ID); err != nil {
-		log.Printf("ERROR: error marking reminder id=%d as sent: %v", pending.ID, err)
-		return err
+// handleSendFailure schedules a retry or dead-letters the reminder.
+// Users who blocked the bot are deactivated so nothing else is sent to them;
+// a group that removed the bot only fails this reminder.
+func (w *Worker) handleSendFailure(ctx context.Context, log *slog.Logger, pending *domain.Reminder, user *domain.User, group bool, sendErr error) error {
+	if telegram.IsBlockedByUser(sendErr) {
+		metrics.ObserveReminderDelivery(metrics.ReminderFailed)
+		if err := w.reminderUC.MarkFailed(ctx, pending, sendErr); err != nil {
+			return err
+		}
+		if group {
+			log.Info("bot can't write to the group, reminder failed")
+			return nil
+		}
+		log.Info("user blocked the bot, deactivating")
+		return w.userUC.Deactivate(ctx, user.ID)
 	}
-	log.Printf("INFO: reminder id=%d marked as sent", pending.ID)
 
+	failed, err := w.reminderUC.RecordFailure(ctx, pending, sendErr, time.Now())
+	if err != nil {
+		return fmt.Errorf("record failure: %w", err)
+	}
+	if failed {
+		metrics.ObserveReminderDelivery(metrics.ReminderFailed)
+		log.Error("reminder failed permanently", "attempts", usecase.MaxDeliveryAttempts, logging.Err(sendErr))
+	} else {
+		metrics.ObserveReminderDelivery(metrics.ReminderRetry)
+		log.Warn("reminder delivery failed, will retry", "attempt", pending.Attempts+1, logging.Err(sendErr))
+	}
 	return nil
 }